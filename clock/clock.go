@@ -0,0 +1,74 @@
+// Package provides a source of time for the disciplines that can be replaced by a
+// manually advanced clock in tests.
+package clock
+
+import "time"
+
+// Source of time used by the disciplines.
+type Clock interface {
+	// Returns the current time
+	Now() time.Time
+
+	// Creates a timer that sends the current time on its channel after at least the
+	// specified duration
+	NewTimer(duration time.Duration) Timer
+
+	// Pauses the current goroutine for at least the specified duration
+	Sleep(duration time.Duration)
+}
+
+// Timer created by the clock.
+//
+// Like the timers of the time package since Go 1.23, no stale values are received
+// from the channel after the Reset or Stop methods are called.
+type Timer interface {
+	// Returns the channel on which the time is delivered
+	C() <-chan time.Time
+
+	// Changes the timer to expire after the specified duration. Returns true if the
+	// timer had been active
+	Reset(duration time.Duration) bool
+
+	// Prevents the timer from firing. Returns true if the timer had been active
+	Stop() bool
+}
+
+// Clock based on the time package.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) NewTimer(duration time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(duration)}
+}
+
+func (Real) Sleep(duration time.Duration) {
+	time.Sleep(duration)
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (rtm realTimer) C() <-chan time.Time {
+	return rtm.timer.C
+}
+
+func (rtm realTimer) Reset(duration time.Duration) bool {
+	return rtm.timer.Reset(duration)
+}
+
+func (rtm realTimer) Stop() bool {
+	return rtm.timer.Stop()
+}
+
+// Returns the specified clock or the [Real] clock if it is not specified.
+func Ensure(clock Clock) Clock {
+	if clock == nil {
+		return Real{}
+	}
+
+	return clock
+}
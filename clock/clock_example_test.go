@@ -0,0 +1,43 @@
+package clock_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/join"
+)
+
+func ExampleManual() {
+	input := make(chan int)
+
+	manual := clock.NewManual(time.Now())
+
+	opts := join.Opts[int]{
+		Clock:    manual,
+		Input:    input,
+		JoinSize: 10,
+		Timeout:  time.Minute,
+	}
+
+	discipline, err := join.New(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	manual.BlockUntil(1)
+
+	input <- 1
+	input <- 2
+
+	manual.Advance(time.Minute)
+
+	fmt.Println(<-discipline.Output())
+
+	close(input)
+
+	for join := range discipline.Output() {
+		fmt.Println(join)
+	}
+	// Output: [1 2]
+}
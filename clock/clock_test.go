@@ -0,0 +1,35 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsure(t *testing.T) {
+	require.Equal(t, Real{}, Ensure(nil))
+
+	manual := NewManual(time.Time{})
+	require.Same(t, manual, Ensure(manual))
+}
+
+func TestReal(t *testing.T) {
+	const duration = time.Millisecond
+
+	clock := Real{}
+
+	startedAt := clock.Now()
+
+	clock.Sleep(duration)
+	require.GreaterOrEqual(t, clock.Now().Sub(startedAt), duration)
+
+	timer := clock.NewTimer(duration)
+
+	<-timer.C()
+	require.GreaterOrEqual(t, clock.Now().Sub(startedAt), 2*duration)
+
+	require.False(t, timer.Reset(time.Hour))
+	require.True(t, timer.Stop())
+	require.False(t, timer.Stop())
+}
@@ -0,0 +1,208 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock whose time is changed only by the [Manual.Advance] method. Is used for fast
+// and deterministic tests of the code that uses the disciplines.
+//
+// Timers fire and sleeping goroutines wake up when the time is advanced to or past
+// their deadlines.
+type Manual struct {
+	mutex    sync.Mutex
+	changed  *sync.Cond
+	now      time.Time
+	sleepers map[*sleeper]struct{}
+	timers   map[*manualTimer]struct{}
+}
+
+type sleeper struct {
+	deadline time.Time
+	wakeup   chan struct{}
+}
+
+// Creates a manual clock that starts at the specified time.
+func NewManual(now time.Time) *Manual {
+	mnl := &Manual{
+		now:      now,
+		sleepers: make(map[*sleeper]struct{}),
+		timers:   make(map[*manualTimer]struct{}),
+	}
+
+	mnl.changed = sync.NewCond(&mnl.mutex)
+
+	return mnl
+}
+
+func (mnl *Manual) Now() time.Time {
+	mnl.mutex.Lock()
+	defer mnl.mutex.Unlock()
+
+	return mnl.now
+}
+
+func (mnl *Manual) NewTimer(duration time.Duration) Timer {
+	mnl.mutex.Lock()
+	defer mnl.mutex.Unlock()
+
+	tmr := &manualTimer{
+		clock: mnl,
+		c:     make(chan time.Time, 1),
+	}
+
+	mnl.arm(tmr, duration)
+
+	return tmr
+}
+
+func (mnl *Manual) Sleep(duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+
+	slp := &sleeper{
+		wakeup: make(chan struct{}),
+	}
+
+	mnl.mutex.Lock()
+
+	slp.deadline = mnl.now.Add(duration)
+	mnl.sleepers[slp] = struct{}{}
+	mnl.changed.Broadcast()
+
+	mnl.mutex.Unlock()
+
+	<-slp.wakeup
+}
+
+// Advances the time by the specified duration, fires the timers and wakes up the
+// sleeping goroutines whose deadlines have been reached.
+//
+// Timers fire in the order of their deadlines with the time of the clock after the
+// advance.
+func (mnl *Manual) Advance(duration time.Duration) {
+	mnl.mutex.Lock()
+	defer mnl.mutex.Unlock()
+
+	mnl.now = mnl.now.Add(max(duration, 0))
+
+	expired := make([]*manualTimer, 0, len(mnl.timers))
+
+	for tmr := range mnl.timers {
+		if !tmr.deadline.After(mnl.now) {
+			expired = append(expired, tmr)
+		}
+	}
+
+	slices.SortFunc(expired, func(first, second *manualTimer) int {
+		return first.deadline.Compare(second.deadline)
+	})
+
+	for _, tmr := range expired {
+		mnl.fire(tmr)
+	}
+
+	for slp := range mnl.sleepers {
+		if !slp.deadline.After(mnl.now) {
+			delete(mnl.sleepers, slp)
+			close(slp.wakeup)
+		}
+	}
+
+	mnl.changed.Broadcast()
+}
+
+// Returns the quantity of active timers and sleeping goroutines.
+func (mnl *Manual) Waiters() int {
+	mnl.mutex.Lock()
+	defer mnl.mutex.Unlock()
+
+	return mnl.waiters()
+}
+
+// Blocks until the quantity of active timers and sleeping goroutines becomes at
+// least the specified value. Is used to make sure that the discipline is waiting
+// for the time before advancing it.
+func (mnl *Manual) BlockUntil(quantity int) {
+	mnl.mutex.Lock()
+	defer mnl.mutex.Unlock()
+
+	for mnl.waiters() < quantity {
+		mnl.changed.Wait()
+	}
+}
+
+func (mnl *Manual) waiters() int {
+	return len(mnl.timers) + len(mnl.sleepers)
+}
+
+// Must be called with the locked mutex.
+func (mnl *Manual) arm(tmr *manualTimer, duration time.Duration) {
+	tmr.deadline = mnl.now.Add(duration)
+
+	if duration <= 0 {
+		mnl.fire(tmr)
+		return
+	}
+
+	mnl.timers[tmr] = struct{}{}
+	mnl.changed.Broadcast()
+}
+
+// Must be called with the locked mutex.
+func (mnl *Manual) fire(tmr *manualTimer) {
+	delete(mnl.timers, tmr)
+
+	select {
+	case tmr.c <- mnl.now:
+	default:
+	}
+}
+
+// Must be called with the locked mutex.
+func (mnl *Manual) disarm(tmr *manualTimer) bool {
+	_, active := mnl.timers[tmr]
+
+	delete(mnl.timers, tmr)
+
+	// Protection against receiving stale values
+	select {
+	case <-tmr.c:
+	default:
+	}
+
+	mnl.changed.Broadcast()
+
+	return active
+}
+
+type manualTimer struct {
+	clock    *Manual
+	c        chan time.Time
+	deadline time.Time
+}
+
+func (tmr *manualTimer) C() <-chan time.Time {
+	return tmr.c
+}
+
+func (tmr *manualTimer) Reset(duration time.Duration) bool {
+	tmr.clock.mutex.Lock()
+	defer tmr.clock.mutex.Unlock()
+
+	active := tmr.clock.disarm(tmr)
+
+	tmr.clock.arm(tmr, duration)
+
+	return active
+}
+
+func (tmr *manualTimer) Stop() bool {
+	tmr.clock.mutex.Lock()
+	defer tmr.clock.mutex.Unlock()
+
+	return tmr.clock.disarm(tmr)
+}
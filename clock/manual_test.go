@@ -0,0 +1,116 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	clock := NewManual(start)
+	require.Equal(t, start, clock.Now())
+
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), clock.Now())
+
+	clock.Advance(-time.Second)
+	require.Equal(t, start.Add(time.Second), clock.Now())
+}
+
+func TestManualTimer(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	clock := NewManual(start)
+
+	timer := clock.NewTimer(time.Second)
+	require.Equal(t, 1, clock.Waiters())
+
+	clock.Advance(time.Second - time.Nanosecond)
+	require.Empty(t, timer.C())
+
+	clock.Advance(time.Nanosecond)
+	require.Equal(t, start.Add(time.Second), <-timer.C())
+	require.Equal(t, 0, clock.Waiters())
+
+	require.False(t, timer.Reset(time.Second))
+	require.Equal(t, 1, clock.Waiters())
+
+	require.True(t, timer.Stop())
+	require.Equal(t, 0, clock.Waiters())
+
+	clock.Advance(time.Second)
+	require.Empty(t, timer.C())
+}
+
+func TestManualTimerStale(t *testing.T) {
+	clock := NewManual(time.Time{})
+
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(time.Second)
+	require.False(t, timer.Reset(time.Second))
+	require.Empty(t, timer.C())
+
+	clock.Advance(time.Second)
+	require.False(t, timer.Stop())
+	require.Empty(t, timer.C())
+}
+
+func TestManualTimerNonPositive(t *testing.T) {
+	clock := NewManual(time.Time{})
+
+	timer := clock.NewTimer(0)
+	require.Equal(t, time.Time{}, <-timer.C())
+	require.Equal(t, 0, clock.Waiters())
+
+	require.False(t, timer.Reset(-time.Second))
+	require.Equal(t, time.Time{}, <-timer.C())
+}
+
+func TestManualTimersOrder(t *testing.T) {
+	clock := NewManual(time.Time{})
+
+	first := clock.NewTimer(time.Second)
+	second := clock.NewTimer(2 * time.Second)
+	third := clock.NewTimer(3 * time.Second)
+
+	clock.Advance(2 * time.Second)
+	require.Len(t, first.C(), 1)
+	require.Len(t, second.C(), 1)
+	require.Empty(t, third.C())
+	require.Equal(t, 1, clock.Waiters())
+}
+
+func TestManualSleep(t *testing.T) {
+	clock := NewManual(time.Time{})
+
+	clock.Sleep(0)
+	clock.Sleep(-time.Second)
+
+	woken := make(chan struct{})
+
+	go func() {
+		defer close(woken)
+
+		clock.Sleep(time.Second)
+	}()
+
+	clock.BlockUntil(1)
+
+	clock.Advance(time.Second - time.Nanosecond)
+
+	select {
+	case <-woken:
+		require.FailNow(t, "goroutine woke up before the deadline")
+	default:
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	<-woken
+
+	require.Equal(t, 0, clock.Waiters())
+}
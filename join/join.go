@@ -4,28 +4,56 @@
 package join
 
 import (
+	"context"
 	"errors"
+	"math"
 	"slices"
 	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
 )
 
 var (
-	ErrInputEmpty   = errors.New("input channel was not specified")
-	ErrJoinSizeZero = errors.New("join size is zero")
+	ErrInputEmpty    = errors.New("input channel was not specified")
+	ErrJoinSizeZero  = errors.New("join size is zero")
+	ErrMaxWeightZero = errors.New("maximum weight is zero")
+	ErrWeightEmpty   = errors.New("weight function was not specified")
 )
 
 // Options of the created discipline.
 type Opts[Type any] struct {
+	// Source of time used to measure the timeout. Is intended mainly for tests, in
+	// which the manual clock allows to advance the time and to determine exactly when
+	// the accumulated slice is written to the output channel. If not specified, the
+	// real time is used
+	Clock clock.Clock
+
+	// By default, when the context passed to the NewContext function is canceled,
+	// the accumulated slice is written to the output channel if there is free space
+	// in it, including the slice whose writing to the full output channel was
+	// interrupted by the cancellation. If the Drop is set to true, then the
+	// accumulated slice will be discarded
+	Drop bool
+
 	// Input channel of data items. For terminate the discipline it is necessary and
-	// sufficient to close the input channel. Preferably input channel should be
-	// buffered for performance reasons. Optimal capacity is in the range of 1 to 3
-	// size of join
+	// sufficient to close the input channel or to cancel the context passed to the
+	// NewContext function. Preferably input channel should be buffered for
+	// performance reasons. Optimal capacity is in the range of 1 to 3 size of join
 	Input <-chan Type
 
 	// Maximum size of the output slice. Actual size of the output slice may be
-	// smaller due to the timeout or closure of the input channel
+	// smaller due to the timeout, closure of the input channel or reaching the
+	// maximum weight
 	JoinSize uint
 
+	// Maximum total weight of data items in the output slice. The accumulated slice
+	// is written to the output channel when adding the next data item would exceed
+	// this value or when this value is reached. A data item whose weight is not less
+	// than this value is written to the output channel alone in a separate slice.
+	// Must be specified together with the Weight function
+	MaxWeight uint
+
 	// By default, to the output channel is written a copy of the accumulated slice.
 	// If the NoCopy is set to true, then to the output channel will be directly
 	// written the accumulated slice. In this case, after the accumulated slice is
@@ -33,6 +61,11 @@ type Opts[Type any] struct {
 	// Release method
 	NoCopy bool
 
+	// Observer of the internal events of the discipline. The discipline reports
+	// writing of the accumulated slice to the output channel by the OnFlush method.
+	// If not specified, events are not reported
+	Observer observe.Observer
+
 	// Timeout value for output slice accumulation. If the output slice has not been
 	// filled completely in the allotted time, then it will be written to the output
 	// channel with the data items accumulated during this time. A zero or negative
@@ -40,6 +73,12 @@ type Opts[Type any] struct {
 	// appear or the channel is closed (in this case, the accumulated slice will be
 	// written to the output channel)
 	Timeout time.Duration
+
+	// Function that returns the weight of a data item, for example, its size in
+	// bytes. Is used together with the MaxWeight to limit the output slice not only
+	// by the quantity of data items. If not specified, the output slice is limited
+	// only by the JoinSize
+	Weight func(Type) uint
 }
 
 func (opts Opts[Type]) isValid() error {
@@ -51,6 +90,14 @@ func (opts Opts[Type]) isValid() error {
 		return ErrJoinSizeZero
 	}
 
+	if opts.Weight != nil && opts.MaxWeight == 0 {
+		return ErrMaxWeightZero
+	}
+
+	if opts.Weight == nil && opts.MaxWeight != 0 {
+		return ErrWeightEmpty
+	}
+
 	return nil
 }
 
@@ -59,6 +106,15 @@ func (opts Opts[Type]) normalize() Opts[Type] {
 		opts.Timeout = 0
 	}
 
+	// Weight of data items is considered to be zero, so the maximum weight is
+	// never reached
+	if opts.Weight == nil {
+		opts.MaxWeight = math.MaxUint
+	}
+
+	opts.Clock = clock.Ensure(opts.Clock)
+	opts.Observer = observe.Ensure(opts.Observer)
+
 	return opts
 }
 
@@ -66,14 +122,23 @@ func (opts Opts[Type]) normalize() Opts[Type] {
 type Discipline[Type any] struct {
 	opts Opts[Type]
 
+	done    <-chan struct{}
+	err     chan error
 	join    []Type
 	output  chan []Type
 	release chan struct{}
-	timer   *time.Timer
+	timer   clock.Timer
+	weight  uint
 }
 
 // Creates and runs discipline.
 func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
+	return NewContext(context.Background(), opts)
+}
+
+// Creates and runs discipline that terminates its work when the specified context
+// is canceled.
+func NewContext[Type any](ctx context.Context, opts Opts[Type]) (*Discipline[Type], error) {
 	if err := opts.isValid(); err != nil {
 		return nil, err
 	}
@@ -89,11 +154,17 @@ func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
 		// integer overflow due to adding one, the resulting value can only become
 		// negative, which will cause a panic when executing make() as same as when
 		// specifying a large positive value
-		output:  make(chan []Type, 1+cap(opts.Input)),
-		release: make(chan struct{}),
+		output: make(chan []Type, 1+cap(opts.Input)),
+		// Capacity of one allows to call the Release method for the slice written
+		// to the output channel during termination without blocking, because
+		// only one slice at a time can be in use outside of the discipline
+		release: make(chan struct{}, 1),
+
+		done: ctx.Done(),
+		err:  make(chan error, 1),
 	}
 
-	go dsc.main()
+	go dsc.main(ctx)
 
 	return dsc, nil
 }
@@ -116,81 +187,188 @@ func (dsc *Discipline[Type]) Release() {
 	}
 }
 
-func (dsc *Discipline[Type]) main() {
+// Returns a channel with errors. If an error occurs (the value from the channel
+// is not equal to nil) the discipline terminates its work.
+//
+// The single nil value means that the discipline has terminated in normal mode:
+// after closing and emptying the input channel.
+//
+// The only place where the error can occurs is the context passed to the NewContext
+// function. In this case the cause of the context cancellation is returned.
+func (dsc *Discipline[Type]) Err() <-chan error {
+	return dsc.err
+}
+
+func (dsc *Discipline[Type]) main(ctx context.Context) {
+	defer close(dsc.err)
 	defer close(dsc.output)
-	defer close(dsc.release)
 
+	if interrupted := dsc.run(); interrupted {
+		dsc.flush()
+		dsc.err <- context.Cause(ctx)
+	}
+}
+
+func (dsc *Discipline[Type]) run() bool {
 	if dsc.opts.Timeout == 0 {
-		dsc.loopWithoutTimeout()
-		return
+		return dsc.loopWithoutTimeout()
 	}
 
-	dsc.loop()
+	return dsc.loop()
 }
 
-func (dsc *Discipline[Type]) loopWithoutTimeout() {
-	defer dsc.pass()
+func (dsc *Discipline[Type]) loopWithoutTimeout() bool {
+	for {
+		select {
+		case <-dsc.done:
+			return true
+		case item, opened := <-dsc.opts.Input:
+			if !opened {
+				return dsc.pass(observe.FlushReasonClosing)
+			}
 
-	for item := range dsc.opts.Input {
-		dsc.add(item)
+			if interrupted := dsc.add(item); interrupted {
+				return true
+			}
+		}
 	}
 }
 
-func (dsc *Discipline[Type]) loop() {
-	dsc.timer = time.NewTimer(dsc.opts.Timeout)
+func (dsc *Discipline[Type]) loop() bool {
+	dsc.timer = dsc.opts.Clock.NewTimer(dsc.opts.Timeout)
 	defer dsc.timer.Stop()
 
-	defer dsc.pass()
-
 	for {
 		select {
-		case <-dsc.timer.C:
-			dsc.pass()
+		case <-dsc.done:
+			return true
+		case <-dsc.timer.C():
+			if interrupted := dsc.pass(observe.FlushReasonTimeout); interrupted {
+				return true
+			}
 		case item, opened := <-dsc.opts.Input:
 			if !opened {
-				return
+				return dsc.pass(observe.FlushReasonClosing)
 			}
 
-			dsc.add(item)
+			if interrupted := dsc.add(item); interrupted {
+				return true
+			}
 		}
 	}
 }
 
-func (dsc *Discipline[Type]) add(item Type) {
+func (dsc *Discipline[Type]) add(item Type) bool {
+	weight := dsc.weigh(item)
+
+	// Integer overflow is impossible because the accumulated weight never exceeds
+	// the maximum weight
+	if weight > dsc.opts.MaxWeight-dsc.weight {
+		if interrupted := dsc.pass(observe.FlushReasonWeight); interrupted {
+			return true
+		}
+	}
+
 	dsc.join = append(dsc.join, item)
 
+	// Weight is limited by the free weight to avoid integer overflow. It is
+	// possible to limit it only for a data item heavier than the maximum weight,
+	// which is here added to the empty slice, and then the slice is written
+	// immediately
+	dsc.weight += min(weight, dsc.opts.MaxWeight-dsc.weight)
+
 	// Integer overflow is impossible because len() function returns only positive
 	// values for type int and the maximum value for type int is less than the
 	// maximum value for type uint
 	if uint(len(dsc.join)) < dsc.opts.JoinSize {
-		return
+		if dsc.weight < dsc.opts.MaxWeight {
+			return false
+		}
+
+		return dsc.pass(observe.FlushReasonWeight)
 	}
 
-	dsc.pass()
+	return dsc.pass(observe.FlushReasonSize)
 }
 
-func (dsc *Discipline[Type]) pass() {
+func (dsc *Discipline[Type]) weigh(item Type) uint {
+	if dsc.opts.Weight == nil {
+		return 0
+	}
+
+	return dsc.opts.Weight(item)
+}
+
+func (dsc *Discipline[Type]) pass(reason observe.FlushReason) bool {
 	if len(dsc.join) == 0 {
 		// defer statement is not used to allow inlining of the current function
 		dsc.resetTimer()
-		return
+		return false
+	}
+
+	written, interrupted := dsc.send(dsc.join, reason)
+
+	// Accumulated slice that has not been written to the output channel due to the
+	// interruption is kept to be written at termination
+	if !written {
+		return true
 	}
 
-	dsc.send(dsc.join)
 	dsc.resetJoin()
 	dsc.resetTimer()
+
+	return interrupted
 }
 
-func (dsc *Discipline[Type]) send(item []Type) {
+// Writes the slice to the output channel and, in no copy mode, waits for its
+// release. Returns whether the slice is written and whether the sending is
+// interrupted.
+func (dsc *Discipline[Type]) send(item []Type, reason observe.FlushReason) (bool, bool) {
 	item = dsc.prepareItem(item)
 
-	dsc.output <- item
+	select {
+	case <-dsc.done:
+		return false, true
+	case dsc.output <- item:
+	}
 
-	if dsc.opts.NoCopy {
-		<-dsc.release
+	dsc.observeFlush(reason, item)
+
+	if !dsc.opts.NoCopy {
+		return true, false
+	}
+
+	select {
+	case <-dsc.done:
+		return true, true
+	case <-dsc.release:
+	}
+
+	return true, false
+}
+
+// Writes the accumulated slice to the output channel at termination by the context
+// without blocking and without waiting for the slice to be released, because after
+// termination the accumulated slice is no longer used by the discipline.
+func (dsc *Discipline[Type]) flush() {
+	if dsc.opts.Drop || len(dsc.join) == 0 {
+		return
+	}
+
+	select {
+	case dsc.output <- dsc.prepareItem(dsc.join):
+		dsc.observeFlush(observe.FlushReasonCancellation, dsc.join)
+	default:
 	}
 }
 
+func (dsc *Discipline[Type]) observeFlush(reason observe.FlushReason, item []Type) {
+	// Integer overflow is impossible because len() function returns only positive
+	// values for type int and the maximum value for type int is less than the
+	// maximum value for type uint
+	dsc.opts.Observer.OnFlush(reason, uint(len(item)))
+}
+
 func (dsc *Discipline[Type]) prepareItem(item []Type) []Type {
 	if dsc.opts.NoCopy {
 		return item
@@ -201,6 +379,7 @@ func (dsc *Discipline[Type]) prepareItem(item []Type) []Type {
 
 func (dsc *Discipline[Type]) resetJoin() {
 	dsc.join = dsc.join[:0]
+	dsc.weight = 0
 }
 
 func (dsc *Discipline[Type]) resetTimer() {
@@ -1,11 +1,16 @@
 package join
 
 import (
+	"context"
+	"errors"
+	"math"
 	"slices"
 	"testing"
 	"time"
 
+	"github.com/akramarenkov/flow/clock"
 	"github.com/akramarenkov/flow/join/internal/defaults"
+	"github.com/akramarenkov/flow/observe"
 
 	"github.com/akramarenkov/safe"
 	"github.com/stretchr/testify/require"
@@ -51,6 +56,34 @@ func TestOptsValidation(t *testing.T) {
 
 	_, err = New(opts)
 	require.NoError(t, err)
+
+	opts = Opts[int]{
+		Input:    make(chan int),
+		JoinSize: 10,
+		Weight:   func(int) uint { return 1 },
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Input:     make(chan int),
+		JoinSize:  10,
+		MaxWeight: 10,
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Input:     make(chan int),
+		JoinSize:  10,
+		MaxWeight: 10,
+		Weight:    func(int) uint { return 1 },
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
 }
 
 func TestDiscipline(t *testing.T) {
@@ -320,6 +353,335 @@ func TestDisciplineTimeoutJoinSize4(t *testing.T) {
 	testDisciplineParallel(t, data, 4, true, timeout, 6, pause, expAt6, durAt6)
 }
 
+func TestDisciplineWeight(t *testing.T) {
+	testDisciplineWeight(t, false)
+	testDisciplineWeight(t, true)
+}
+
+func testDisciplineWeight(t *testing.T, noCopy bool) {
+	// Weight of data item is equal to its value
+	data := []uint{
+		1, 2, 3, 4, 10, 1, 1, 12, 5, 5, 6, 4, 1, 1, 1, 1, 1, 1, 1, 2,
+	}
+
+	expected := [][]uint{
+		{1, 2, 3, 4}, {10}, {1, 1}, {12}, {5, 5}, {6, 4},
+		{1, 1, 1, 1, 1}, {1, 1, 2},
+	}
+
+	input := make(chan uint, len(data))
+
+	opts := Opts[uint]{
+		Input:     input,
+		JoinSize:  5,
+		MaxWeight: 10,
+		NoCopy:    noCopy,
+		Weight:    func(item uint) uint { return item },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, item := range data {
+		input <- item
+	}
+
+	close(input)
+
+	output := make([][]uint, 0, len(expected))
+
+	for join := range discipline.Output() {
+		output = append(output, slices.Clone(join))
+		discipline.Release()
+	}
+
+	require.Equal(t, expected, output)
+}
+
+func TestDisciplineWeightOverflow(t *testing.T) {
+	data := []uint{1, math.MaxUint, math.MaxUint - 1, 1, 2}
+
+	expected := [][]uint{{1}, {math.MaxUint}, {math.MaxUint - 1, 1}, {2}}
+
+	input := make(chan uint, len(data))
+
+	opts := Opts[uint]{
+		Input:     input,
+		JoinSize:  10,
+		MaxWeight: math.MaxUint,
+		Weight:    func(item uint) uint { return item },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, item := range data {
+		input <- item
+	}
+
+	close(input)
+
+	output := make([][]uint, 0, len(expected))
+
+	for join := range discipline.Output() {
+		output = append(output, join)
+	}
+
+	require.Equal(t, expected, output)
+}
+
+func TestDisciplineObserver(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	input := make(chan uint)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[uint]{
+		Input:     input,
+		JoinSize:  3,
+		MaxWeight: 10,
+		Observer:  counters,
+		Timeout:   timeout,
+		Weight:    func(item uint) uint { return item },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 1
+	input <- 1
+
+	require.Equal(t, []uint{1, 1, 1}, <-discipline.Output())
+
+	input <- 9
+	input <- 2
+
+	require.Equal(t, []uint{9}, <-discipline.Output())
+	require.Equal(t, []uint{2}, <-discipline.Output())
+
+	input <- 5
+
+	close(input)
+
+	require.Equal(t, []uint{5}, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonSize))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonWeight))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonTimeout))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonClosing))
+	require.Equal(t, uint64(6), counters.FlushedItems())
+}
+
+func TestDisciplineClock(t *testing.T) {
+	const timeout = time.Second
+
+	input := make(chan int)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock:    manual,
+		Input:    input,
+		JoinSize: 10,
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	manual.BlockUntil(1)
+
+	input <- 1
+	input <- 2
+
+	manual.Advance(timeout - time.Nanosecond)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, []int{1, 2}, <-discipline.Output())
+
+	// Timeout is counted from the previous writing to the output channel
+	manual.BlockUntil(1)
+
+	input <- 3
+
+	manual.Advance(timeout / 2)
+
+	input <- 4
+
+	manual.Advance(timeout / 2)
+	require.Equal(t, []int{3, 4}, <-discipline.Output())
+
+	close(input)
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineContext(t *testing.T) {
+	testDisciplineContext(t, false, false, defaults.TestTimeout, [][]int{{1, 2, 3}})
+	testDisciplineContext(t, false, true, defaults.TestTimeout, [][]int{{1, 2, 3}})
+	testDisciplineContext(t, false, false, 0, [][]int{{1, 2, 3}})
+	testDisciplineContext(t, false, true, 0, [][]int{{1, 2, 3}})
+
+	testDisciplineContext(t, true, false, defaults.TestTimeout, [][]int{})
+	testDisciplineContext(t, true, true, defaults.TestTimeout, [][]int{})
+	testDisciplineContext(t, true, false, 0, [][]int{})
+	testDisciplineContext(t, true, true, 0, [][]int{})
+}
+
+func testDisciplineContext(
+	t *testing.T,
+	drop bool,
+	noCopy bool,
+	timeout time.Duration,
+	expected [][]int,
+) {
+	errCause := errors.New("cause")
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	defer cancel(nil)
+
+	input := make(chan int, 3)
+	defer close(input)
+
+	opts := Opts[int]{
+		Drop:     drop,
+		Input:    input,
+		JoinSize: 10,
+		NoCopy:   noCopy,
+		Timeout:  timeout,
+	}
+
+	discipline, err := NewContext(ctx, opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 2
+	input <- 3
+
+	// Waiting for the data items to be read by the discipline
+	for len(input) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel(errCause)
+
+	output := make([][]int, 0, len(expected))
+
+	for join := range discipline.Output() {
+		output = append(output, slices.Clone(join))
+		discipline.Release()
+	}
+
+	require.Equal(t, expected, output)
+	require.ErrorIs(t, <-discipline.Err(), errCause)
+}
+
+func TestDisciplineContextStuckConsumer(t *testing.T) {
+	testDisciplineContextStuckConsumer(t, false)
+	testDisciplineContextStuckConsumer(t, true)
+}
+
+func testDisciplineContextStuckConsumer(t *testing.T, noCopy bool) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	input := make(chan int)
+	defer close(input)
+
+	opts := Opts[int]{
+		Input:    input,
+		JoinSize: 1,
+		NoCopy:   noCopy,
+	}
+
+	discipline, err := NewContext(ctx, opts)
+	require.NoError(t, err)
+
+	// Output channel has a capacity of one, so in copy mode the discipline is
+	// blocked when writing the second slice and in no copy mode the discipline is
+	// blocked when waiting for the release of the first slice
+	input <- 1
+
+	if !noCopy {
+		input <- 2
+	}
+
+	cancel()
+
+	require.ErrorIs(t, <-discipline.Err(), context.Canceled)
+
+	output := make([][]int, 0, 1)
+
+	for join := range discipline.Output() {
+		output = append(output, slices.Clone(join))
+	}
+
+	require.Equal(t, [][]int{{1}}, output)
+
+	// Release after termination does not block
+	discipline.Release()
+}
+
+func TestDisciplineContextInterruptedSlice(t *testing.T) {
+	const repetitions = 1000
+
+	for range repetitions {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		input := make(chan int, 1)
+		input <- 1
+
+		opts := Opts[int]{
+			Input:    input,
+			JoinSize: 1,
+		}
+
+		// Data item is either not read or read and written to the output channel,
+		// even if the writing of the slice is interrupted by the cancellation
+		discipline, err := NewContext(ctx, opts)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, <-discipline.Err(), context.Canceled)
+
+		received := 0
+
+		for join := range discipline.Output() {
+			received += len(join)
+		}
+
+		require.Equal(t, 1, received+len(input))
+	}
+}
+
+func TestDisciplineContextNormalTermination(t *testing.T) {
+	input := make(chan int, 3)
+
+	opts := Opts[int]{
+		Input:    input,
+		JoinSize: 10,
+	}
+
+	discipline, err := NewContext(t.Context(), opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 2
+	input <- 3
+
+	close(input)
+
+	require.Equal(t, []int{1, 2, 3}, <-discipline.Output())
+	require.NoError(t, <-discipline.Err())
+}
+
 func testDiscipline(
 	t *testing.T,
 	data []int,
@@ -0,0 +1,401 @@
+// Discipline used to accumulate data items from an input channel into separate
+// slices by keys of the data items and write each slice to an output channel when the
+// maximum slice size or timeout for its accumulation is reached. It works like a
+// join discipline but keeps one accumulated slice and one timeout per key.
+package keyed
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
+)
+
+var (
+	ErrInputEmpty   = errors.New("input channel was not specified")
+	ErrJoinSizeZero = errors.New("join size is zero")
+	ErrKeyEmpty     = errors.New("key function was not specified")
+)
+
+// Options of the created discipline.
+type Opts[Key comparable, Type any] struct {
+	// Source of time used to measure the timeout. Is intended mainly for tests, in
+	// which the manual clock allows to advance the time and to determine exactly when
+	// the accumulated slice is written to the output channel. If not specified, the
+	// real time is used
+	Clock clock.Clock
+
+	// Input channel of data items. For terminate the discipline it is necessary and
+	// sufficient to close the input channel. Preferably input channel should be
+	// buffered for performance reasons. Optimal capacity is in the range of 1 to 3
+	// size of join
+	Input <-chan Type
+
+	// Maximum size of the output slice for each key. Actual size of the output
+	// slice may be smaller due to the timeout, closure of the input channel or
+	// eviction of the key
+	JoinSize uint
+
+	// Function that returns the key of a data item. Data items with equal keys are
+	// accumulated into the same slice
+	Key func(Type) Key
+
+	// Maximum quantity of keys for which data items are accumulated at the same
+	// time. If a data item with a new key is received when this quantity is
+	// reached, then the slice of the oldest key is written to the output channel
+	// with the data items accumulated to this moment. A zero value means that the
+	// quantity of keys is not limited
+	MaxKeys uint
+
+	// By default, to the output channel is written a copy of the accumulated slice.
+	// If the NoCopy is set to true, then to the output channel will be directly
+	// written the accumulated slice. In this case, after the accumulated slice is
+	// no longer used it is necessary to inform the discipline about it by calling
+	// Release method
+	NoCopy bool
+
+	// Observer of the internal events of the discipline. The discipline reports
+	// writing of the accumulated slice to the output channel by the OnFlush method.
+	// If not specified, events are not reported
+	Observer observe.Observer
+
+	// Timeout value for output slice accumulation. It is counted separately for
+	// each key from the moment the first data item with that key is added to the
+	// slice. If the output slice has not been filled completely in the allotted time,
+	// then it will be written to the output channel with the data items accumulated
+	// during this time. A zero or negative value means that discipline will wait
+	// for the missing data items until they appear or the channel is closed (in this
+	// case, all accumulated slices will be written to the output channel)
+	Timeout time.Duration
+}
+
+func (opts Opts[Key, Type]) isValid() error {
+	if opts.Input == nil {
+		return ErrInputEmpty
+	}
+
+	if opts.JoinSize == 0 {
+		return ErrJoinSizeZero
+	}
+
+	if opts.Key == nil {
+		return ErrKeyEmpty
+	}
+
+	return nil
+}
+
+func (opts Opts[Key, Type]) normalize() Opts[Key, Type] {
+	if opts.Timeout < 0 {
+		opts.Timeout = 0
+	}
+
+	opts.Clock = clock.Ensure(opts.Clock)
+	opts.Observer = observe.Ensure(opts.Observer)
+
+	return opts
+}
+
+// Slice of data items with the same key written to the output channel.
+type Batch[Key comparable, Type any] struct {
+	Items []Type
+	Key   Key
+}
+
+// Accumulated slice of data items with the same key.
+type join[Key comparable, Type any] struct {
+	deadline time.Time
+	items    []Type
+	key      Key
+
+	// Neighboring joins in order of creation
+	newer *join[Key, Type]
+	older *join[Key, Type]
+}
+
+// Keyed join discipline.
+type Discipline[Key comparable, Type any] struct {
+	opts Opts[Key, Type]
+
+	joins   map[Key]*join[Key, Type]
+	output  chan Batch[Key, Type]
+	release chan struct{}
+	spare   [][]Type
+	timer   clock.Timer
+
+	// Joins are linked in order of their creation, which is also the order of their
+	// deadlines because the timeout is the same for all keys
+	newest *join[Key, Type]
+	oldest *join[Key, Type]
+}
+
+// Creates and runs discipline.
+func New[Key comparable, Type any](
+	opts Opts[Key, Type],
+) (*Discipline[Key, Type], error) {
+	if err := opts.isValid(); err != nil {
+		return nil, err
+	}
+
+	opts = opts.normalize()
+
+	dsc := &Discipline[Key, Type]{
+		opts: opts,
+
+		joins: make(map[Key]*join[Key, Type]),
+
+		// Value returned by the cap() function is always positive and, in the case of
+		// integer overflow due to adding one, the resulting value can only become
+		// negative, which will cause a panic when executing make() as same as when
+		// specifying a large positive value
+		output:  make(chan Batch[Key, Type], 1+cap(opts.Input)),
+		release: make(chan struct{}),
+	}
+
+	go dsc.main()
+
+	return dsc, nil
+}
+
+// Returns output channel.
+//
+// If this channel is closed, it means that the discipline is terminated.
+func (dsc *Discipline[Key, Type]) Output() <-chan Batch[Key, Type] {
+	return dsc.output
+}
+
+// Marks output slice as no longer used outside of the discipline.
+//
+// Must be called, if NoCopy option is set to true, after the output slice is
+// no longer used outside of the discipline. However, calling this method is also
+// possible if the NoCopy option is set to false.
+func (dsc *Discipline[Key, Type]) Release() {
+	if dsc.opts.NoCopy {
+		dsc.release <- struct{}{}
+	}
+}
+
+func (dsc *Discipline[Key, Type]) main() {
+	defer close(dsc.output)
+	defer close(dsc.release)
+
+	if dsc.opts.Timeout == 0 {
+		dsc.loopWithoutTimeout()
+		return
+	}
+
+	dsc.loop()
+}
+
+func (dsc *Discipline[Key, Type]) loopWithoutTimeout() {
+	defer dsc.passAll()
+
+	for item := range dsc.opts.Input {
+		dsc.add(item)
+	}
+}
+
+func (dsc *Discipline[Key, Type]) loop() {
+	dsc.timer = dsc.opts.Clock.NewTimer(dsc.opts.Timeout)
+	defer dsc.timer.Stop()
+
+	// Timer is started only when the first join is created
+	dsc.timer.Stop()
+
+	defer dsc.passAll()
+
+	for {
+		select {
+		case <-dsc.timer.C():
+			dsc.passExpired()
+		case item, opened := <-dsc.opts.Input:
+			if !opened {
+				return
+			}
+
+			dsc.add(item)
+		}
+	}
+}
+
+func (dsc *Discipline[Key, Type]) add(item Type) {
+	key := dsc.opts.Key(item)
+
+	joined, exists := dsc.joins[key]
+	if !exists {
+		joined = dsc.create(key)
+	}
+
+	joined.items = append(joined.items, item)
+
+	// Integer overflow is impossible because len() function returns only positive
+	// values for type int and the maximum value for type int is less than the
+	// maximum value for type uint
+	if uint(len(joined.items)) < dsc.opts.JoinSize {
+		return
+	}
+
+	dsc.pass(joined, observe.FlushReasonSize)
+}
+
+func (dsc *Discipline[Key, Type]) create(key Key) *join[Key, Type] {
+	// Integer overflow is impossible because len() function returns only positive
+	// values for type int and the maximum value for type int is less than the
+	// maximum value for type uint
+	if dsc.opts.MaxKeys != 0 && uint(len(dsc.joins)) >= dsc.opts.MaxKeys {
+		dsc.pass(dsc.oldest, observe.FlushReasonEviction)
+	}
+
+	joined := &join[Key, Type]{
+		items: dsc.takeSpare(),
+		key:   key,
+	}
+
+	if dsc.opts.Timeout != 0 {
+		joined.deadline = dsc.opts.Clock.Now().Add(dsc.opts.Timeout)
+	}
+
+	dsc.link(joined)
+	dsc.joins[key] = joined
+
+	if dsc.oldest == joined {
+		dsc.resetTimer()
+	}
+
+	return joined
+}
+
+func (dsc *Discipline[Key, Type]) link(joined *join[Key, Type]) {
+	joined.older = dsc.newest
+
+	if dsc.newest != nil {
+		dsc.newest.newer = joined
+	}
+
+	dsc.newest = joined
+
+	if dsc.oldest == nil {
+		dsc.oldest = joined
+	}
+}
+
+func (dsc *Discipline[Key, Type]) unlink(joined *join[Key, Type]) {
+	if joined.older != nil {
+		joined.older.newer = joined.newer
+	} else {
+		dsc.oldest = joined.newer
+	}
+
+	if joined.newer != nil {
+		joined.newer.older = joined.older
+	} else {
+		dsc.newest = joined.older
+	}
+
+	joined.newer = nil
+	joined.older = nil
+}
+
+func (dsc *Discipline[Key, Type]) pass(
+	joined *join[Key, Type],
+	reason observe.FlushReason,
+) {
+	isOldest := dsc.oldest == joined
+
+	dsc.unlink(joined)
+	delete(dsc.joins, joined.key)
+
+	dsc.send(joined.key, joined.items, reason)
+	dsc.putSpare(joined.items)
+
+	if isOldest {
+		dsc.resetTimer()
+	}
+}
+
+func (dsc *Discipline[Key, Type]) passExpired() {
+	now := dsc.opts.Clock.Now()
+
+	for dsc.oldest != nil {
+		if dsc.oldest.deadline.After(now) {
+			dsc.resetTimer()
+			return
+		}
+
+		dsc.pass(dsc.oldest, observe.FlushReasonTimeout)
+	}
+}
+
+func (dsc *Discipline[Key, Type]) passAll() {
+	for dsc.oldest != nil {
+		dsc.pass(dsc.oldest, observe.FlushReasonClosing)
+	}
+}
+
+func (dsc *Discipline[Key, Type]) send(
+	key Key,
+	items []Type,
+	reason observe.FlushReason,
+) {
+	batch := Batch[Key, Type]{
+		Items: dsc.prepareItems(items),
+		Key:   key,
+	}
+
+	dsc.output <- batch
+
+	// Integer overflow is impossible because len() function returns only positive
+	// values for type int and the maximum value for type int is less than the
+	// maximum value for type uint
+	dsc.opts.Observer.OnFlush(reason, uint(len(items)))
+
+	if dsc.opts.NoCopy {
+		<-dsc.release
+	}
+}
+
+func (dsc *Discipline[Key, Type]) prepareItems(items []Type) []Type {
+	if dsc.opts.NoCopy {
+		return items
+	}
+
+	return slices.Clone(items)
+}
+
+// Returns a previously used slice to avoid allocations when creating joins.
+func (dsc *Discipline[Key, Type]) takeSpare() []Type {
+	if len(dsc.spare) == 0 {
+		return make([]Type, 0, dsc.opts.JoinSize)
+	}
+
+	last := len(dsc.spare) - 1
+
+	items := dsc.spare[last]
+	dsc.spare = dsc.spare[:last]
+
+	return items
+}
+
+// Keeps a slice that is no longer used for reuse. Quantity of kept slices does not
+// exceed the maximum quantity of simultaneously existing joins.
+func (dsc *Discipline[Key, Type]) putSpare(items []Type) {
+	clear(items)
+
+	dsc.spare = append(dsc.spare, items[:0])
+}
+
+// Sets the timer to the deadline of the oldest join, if any.
+func (dsc *Discipline[Key, Type]) resetTimer() {
+	if dsc.opts.Timeout == 0 {
+		return
+	}
+
+	if dsc.oldest == nil {
+		dsc.timer.Stop()
+		return
+	}
+
+	dsc.timer.Reset(dsc.oldest.deadline.Sub(dsc.opts.Clock.Now()))
+}
@@ -0,0 +1,57 @@
+package keyed_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akramarenkov/flow/join/keyed"
+)
+
+func ExampleDiscipline() {
+	type event struct {
+		ID     int
+		Tenant string
+	}
+
+	data := []event{
+		{ID: 1, Tenant: "first"},
+		{ID: 2, Tenant: "second"},
+		{ID: 3, Tenant: "first"},
+		{ID: 4, Tenant: "first"},
+		{ID: 5, Tenant: "second"},
+		{ID: 6, Tenant: "first"},
+	}
+
+	// Preferably input channel should be buffered for performance reasons.
+	// Optimal capacity is in the range of 1 to 3 size of join
+	input := make(chan event, 3)
+
+	opts := keyed.Opts[string, event]{
+		Input:    input,
+		JoinSize: 3,
+		Key:      func(item event) string { return item.Tenant },
+		Timeout:  time.Second,
+	}
+
+	discipline, err := keyed.New(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	go func() {
+		defer close(input)
+
+		for _, item := range data {
+			input <- item
+		}
+	}()
+
+	for batch := range discipline.Output() {
+		fmt.Println(batch.Key, batch.Items)
+		discipline.Release()
+	}
+	// Output:
+	// first [{1 first} {3 first} {4 first}]
+	// second [{2 second} {5 second}]
+	// first [{6 first}]
+}
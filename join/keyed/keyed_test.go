@@ -0,0 +1,425 @@
+package keyed
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/join/internal/defaults"
+	"github.com/akramarenkov/flow/observe"
+
+	"github.com/akramarenkov/safe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptsValidation(t *testing.T) {
+	key := func(item int) int { return item % 2 }
+
+	opts := Opts[int, int]{
+		JoinSize: 10,
+		Key:      key,
+	}
+
+	_, err := New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int, int]{
+		Input: make(chan int),
+		Key:   key,
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int, int]{
+		Input:    make(chan int),
+		JoinSize: 10,
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int, int]{
+		Input:    make(chan int),
+		JoinSize: 10,
+		Key:      key,
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
+
+	opts = Opts[int, int]{
+		Input:    make(chan int),
+		JoinSize: 10,
+		Key:      key,
+		Timeout:  defaults.TestTimeout,
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
+
+	opts = Opts[int, int]{
+		Input:    make(chan int),
+		JoinSize: 10,
+		Key:      key,
+		Timeout:  -defaults.TestTimeout,
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
+}
+
+func TestDiscipline(t *testing.T) {
+	data := []int{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10,
+		11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+	}
+
+	expected := []Batch[int, int]{
+		{Key: 1, Items: []int{1, 3, 5}},
+		{Key: 0, Items: []int{2, 4, 6}},
+		{Key: 1, Items: []int{7, 9, 11}},
+		{Key: 0, Items: []int{8, 10, 12}},
+		{Key: 1, Items: []int{13, 15, 17}},
+		{Key: 0, Items: []int{14, 16, 18}},
+		{Key: 1, Items: []int{19}},
+		{Key: 0, Items: []int{20}},
+	}
+
+	testDiscipline(t, data, 3, 2, 0, false, defaults.TestTimeout, 0, 0, expected)
+	testDiscipline(t, data, 3, 2, 0, true, defaults.TestTimeout, 0, 0, expected)
+	testDiscipline(t, data, 3, 2, 0, false, 0, 0, 0, expected)
+	testDiscipline(t, data, 3, 2, 0, true, 0, 0, 0, expected)
+
+	// Limit of keys is not exceeded
+	testDiscipline(t, data, 3, 2, 2, false, 0, 0, 0, expected)
+	testDiscipline(t, data, 3, 2, 2, true, 0, 0, 0, expected)
+}
+
+func TestDisciplineMaxKeys(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	expected := []Batch[int, int]{
+		{Key: 1, Items: []int{1}},
+		{Key: 2, Items: []int{2}},
+		{Key: 0, Items: []int{3}},
+		{Key: 1, Items: []int{4}},
+		{Key: 2, Items: []int{5}},
+		{Key: 0, Items: []int{6}},
+		{Key: 1, Items: []int{7}},
+		{Key: 2, Items: []int{8}},
+		{Key: 0, Items: []int{9}},
+	}
+
+	testDiscipline(t, data, 3, 3, 1, false, defaults.TestTimeout, 0, 0, expected)
+	testDiscipline(t, data, 3, 3, 1, true, 0, 0, 0, expected)
+
+	data = []int{1, 4, 2, 5, 3, 7, 8}
+
+	expected = []Batch[int, int]{
+		{Key: 1, Items: []int{1, 4}},
+		{Key: 2, Items: []int{2, 5}},
+		{Key: 0, Items: []int{3}},
+		{Key: 1, Items: []int{7}},
+		{Key: 2, Items: []int{8}},
+	}
+
+	testDiscipline(t, data, 3, 3, 2, false, defaults.TestTimeout, 0, 0, expected)
+	testDiscipline(t, data, 3, 3, 2, true, 0, 0, 0, expected)
+}
+
+func TestDisciplineTimeout(t *testing.T) {
+	const timeout = 500 * time.Millisecond
+
+	data := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	expected := []Batch[int, int]{
+		{Key: 1, Items: []int{1, 3}},
+		{Key: 0, Items: []int{2, 4}},
+		{Key: 1, Items: []int{5, 7}},
+		{Key: 0, Items: []int{6, 8}},
+	}
+
+	testDiscipline(t, data, 3, 2, 0, false, timeout, 5, 2*timeout, expected)
+	testDiscipline(t, data, 3, 2, 0, true, timeout, 5, 2*timeout, expected)
+}
+
+func TestDisciplineTimeoutPerKey(t *testing.T) {
+	const timeout = 500 * time.Millisecond
+
+	input := make(chan int)
+
+	opts := Opts[int, int]{
+		Input:    input,
+		JoinSize: 10,
+		Key:      func(item int) int { return item % 2 },
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	startedAt := time.Now()
+
+	input <- 1
+
+	time.Sleep(timeout / 2)
+
+	input <- 2
+	input <- 3
+
+	batch := <-discipline.Output()
+	require.Equal(t, Batch[int, int]{Key: 1, Items: []int{1, 3}}, batch)
+	require.InEpsilon(t, timeout, time.Since(startedAt), 0.1)
+
+	batch = <-discipline.Output()
+	require.Equal(t, Batch[int, int]{Key: 0, Items: []int{2}}, batch)
+	require.InEpsilon(t, timeout+timeout/2, time.Since(startedAt), 0.1)
+
+	close(input)
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineObserver(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	input := make(chan int)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[int, int]{
+		Input:    input,
+		JoinSize: 2,
+		Key:      func(item int) int { return item % 2 },
+		MaxKeys:  1,
+		Observer: counters,
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 3
+
+	require.Equal(t, Batch[int, int]{Key: 1, Items: []int{1, 3}}, <-discipline.Output())
+
+	input <- 5
+	input <- 2
+
+	require.Equal(t, Batch[int, int]{Key: 1, Items: []int{5}}, <-discipline.Output())
+	require.Equal(t, Batch[int, int]{Key: 0, Items: []int{2}}, <-discipline.Output())
+
+	input <- 4
+
+	close(input)
+
+	require.Equal(t, Batch[int, int]{Key: 0, Items: []int{4}}, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonSize))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonEviction))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonTimeout))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonClosing))
+	require.Equal(t, uint64(5), counters.FlushedItems())
+}
+
+func TestDisciplineClock(t *testing.T) {
+	const timeout = time.Second
+
+	input := make(chan int)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int, int]{
+		Clock:    manual,
+		Input:    input,
+		JoinSize: 10,
+		Key:      func(item int) int { return item % 2 },
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	// Timer is started only when the first join is created
+	manual.BlockUntil(1)
+	manual.Advance(timeout / 2)
+
+	input <- 2
+	input <- 3
+
+	manual.Advance(timeout/2 - time.Nanosecond)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, Batch[int, int]{Key: 1, Items: []int{1, 3}}, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	manual.Advance(timeout/2 - time.Nanosecond)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, Batch[int, int]{Key: 0, Items: []int{2}}, <-discipline.Output())
+
+	close(input)
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineMutable(t *testing.T) {
+	input := make(chan int)
+
+	opts := Opts[int, int]{
+		Input:    input,
+		JoinSize: 2,
+		Key:      func(item int) int { return item % 2 },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	go func() {
+		defer close(input)
+
+		for item := range 8 {
+			input <- item
+		}
+	}()
+
+	received := make([]Batch[int, int], 0, 4)
+
+	for batch := range discipline.Output() {
+		received = append(received, batch)
+	}
+
+	expected := []Batch[int, int]{
+		{Key: 0, Items: []int{0, 2}},
+		{Key: 1, Items: []int{1, 3}},
+		{Key: 0, Items: []int{4, 6}},
+		{Key: 1, Items: []int{5, 7}},
+	}
+
+	require.Equal(t, expected, received)
+}
+
+func testDiscipline(
+	t *testing.T,
+	data []int,
+	joinSize uint,
+	keysQuantity int,
+	maxKeys uint,
+	noCopy bool,
+	timeout time.Duration,
+	pauseAt int,
+	pauseDuration time.Duration,
+	expected []Batch[int, int],
+) {
+	input := make(chan int, joinSize)
+
+	opts := Opts[int, int]{
+		Input:    input,
+		JoinSize: joinSize,
+		Key:      func(item int) int { return item % keysQuantity },
+		MaxKeys:  maxKeys,
+		NoCopy:   noCopy,
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	output := make([]Batch[int, int], 0, len(expected))
+
+	go func() {
+		defer close(input)
+
+		for _, item := range data {
+			if item == pauseAt {
+				time.Sleep(pauseDuration)
+			}
+
+			input <- item
+		}
+	}()
+
+	for batch := range discipline.Output() {
+		if noCopy {
+			batch.Items = slices.Clone(batch.Items)
+		}
+
+		output = append(output, batch)
+
+		discipline.Release()
+	}
+
+	require.Equal(t, expected, output)
+}
+
+func BenchmarkDiscipline(b *testing.B) {
+	benchmarkDiscipline(b, false, defaults.TestTimeout, 1)
+}
+
+func BenchmarkDisciplineNoCopy(b *testing.B) {
+	benchmarkDiscipline(b, true, defaults.TestTimeout, 1)
+}
+
+func BenchmarkDisciplineUntimeouted(b *testing.B) {
+	benchmarkDiscipline(b, false, 0, 1)
+}
+
+func BenchmarkDisciplineNoCopyUntimeouted(b *testing.B) {
+	benchmarkDiscipline(b, true, 0, 1)
+}
+
+func benchmarkDiscipline(
+	b *testing.B,
+	noCopy bool,
+	timeout time.Duration,
+	capacityFactor float64,
+) {
+	const (
+		joinSize     = 10
+		keysQuantity = 10
+	)
+
+	joinsQuantity := b.N
+
+	quantity, err := safe.Mul(joinsQuantity, joinSize)
+	require.NoError(b, err)
+
+	input := make(chan int, int(capacityFactor*float64(joinSize)))
+
+	opts := Opts[int, int]{
+		Input:    input,
+		JoinSize: joinSize,
+		Key:      func(item int) int { return item % keysQuantity },
+		NoCopy:   noCopy,
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	go func() {
+		defer close(input)
+
+		for item := range quantity {
+			input <- item
+		}
+	}()
+
+	for range discipline.Output() {
+		discipline.Release()
+	}
+}
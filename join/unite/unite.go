@@ -7,17 +7,29 @@ package unite
 
 import (
 	"errors"
+	"math"
 	"slices"
 	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
 )
 
 var (
-	ErrInputEmpty   = errors.New("input channel was not specified")
-	ErrJoinSizeZero = errors.New("join size is zero")
+	ErrInputEmpty    = errors.New("input channel was not specified")
+	ErrJoinSizeZero  = errors.New("join size is zero")
+	ErrMaxWeightZero = errors.New("maximum weight is zero")
+	ErrWeightEmpty   = errors.New("weight function was not specified")
 )
 
 // Options of the created discipline.
 type Opts[Type any] struct {
+	// Source of time used to measure the timeout. Is intended mainly for tests, in
+	// which the manual clock allows to advance the time and to determine exactly when
+	// the accumulated slice is written to the output channel. If not specified, the
+	// real time is used
+	Clock clock.Clock
+
 	// Input channel of data items. For terminate the discipline it is necessary and
 	// sufficient to close the input channel. Preferably input channel should be
 	// buffered for performance reasons. Optimal capacity is in the range of 1 to 3
@@ -25,12 +37,19 @@ type Opts[Type any] struct {
 	Input <-chan []Type
 
 	// Maximum size of the output slice. Actual size of the output slice may be
-	// smaller due to the timeout or closure of the input channel and the fact
-	// that the input slices accumulate entirely. Also, the actual size of the output
-	// slice may be larger if an slice larger than the maximum size is received at
-	// the input
+	// smaller due to the timeout, closure of the input channel, reaching the maximum
+	// weight and the fact that the input slices accumulate entirely. Also, the actual
+	// size of the output slice may be larger if an slice larger than the maximum size
+	// is received at the input
 	JoinSize uint
 
+	// Maximum total weight of data items in the output slice. The accumulated slice
+	// is written to the output channel when adding the next input slice would exceed
+	// this value or when this value is reached. An input slice whose total weight is
+	// not less than this value is written to the output channel alone. Must be
+	// specified together with the Weight function
+	MaxWeight uint
+
 	// By default, to the output channel is written a copy of the accumulated slice.
 	// If the NoCopy is set to true, then to the output channel will be directly
 	// written the accumulated slice. In this case, after the accumulated slice is
@@ -38,6 +57,11 @@ type Opts[Type any] struct {
 	// Release method
 	NoCopy bool
 
+	// Observer of the internal events of the discipline. The discipline reports
+	// writing of the accumulated slice to the output channel by the OnFlush method.
+	// If not specified, events are not reported
+	Observer observe.Observer
+
 	// Timeout value for output slice accumulation. If the output slice has not been
 	// filled completely in the allotted time, then it will be written to the output
 	// channel with the data items accumulated during this time. A zero or negative
@@ -45,6 +69,12 @@ type Opts[Type any] struct {
 	// appear or the channel is closed (in this case, the accumulated slice will be
 	// written to the output channel)
 	Timeout time.Duration
+
+	// Function that returns the weight of a data item, for example, its size in
+	// bytes. Is used together with the MaxWeight to limit the output slice not only
+	// by the quantity of data items. If not specified, the output slice is limited
+	// only by the JoinSize
+	Weight func(Type) uint
 }
 
 func (opts Opts[Type]) isValid() error {
@@ -56,6 +86,14 @@ func (opts Opts[Type]) isValid() error {
 		return ErrJoinSizeZero
 	}
 
+	if opts.Weight != nil && opts.MaxWeight == 0 {
+		return ErrMaxWeightZero
+	}
+
+	if opts.Weight == nil && opts.MaxWeight != 0 {
+		return ErrWeightEmpty
+	}
+
 	return nil
 }
 
@@ -64,6 +102,15 @@ func (opts Opts[Type]) normalize() Opts[Type] {
 		opts.Timeout = 0
 	}
 
+	// Weight of data items is considered to be zero, so the maximum weight is
+	// never reached
+	if opts.Weight == nil {
+		opts.MaxWeight = math.MaxUint
+	}
+
+	opts.Clock = clock.Ensure(opts.Clock)
+	opts.Observer = observe.Ensure(opts.Observer)
+
 	return opts
 }
 
@@ -74,7 +121,8 @@ type Discipline[Type any] struct {
 	join    []Type
 	output  chan []Type
 	release chan struct{}
-	timer   *time.Timer
+	timer   clock.Timer
+	weight  uint
 }
 
 // Creates and runs discipline.
@@ -134,7 +182,7 @@ func (dsc *Discipline[Type]) main() {
 }
 
 func (dsc *Discipline[Type]) loopWithoutTimeout() {
-	defer dsc.pass()
+	defer dsc.pass(observe.FlushReasonClosing)
 
 	for item := range dsc.opts.Input {
 		dsc.add(item)
@@ -142,15 +190,15 @@ func (dsc *Discipline[Type]) loopWithoutTimeout() {
 }
 
 func (dsc *Discipline[Type]) loop() {
-	dsc.timer = time.NewTimer(dsc.opts.Timeout)
+	dsc.timer = dsc.opts.Clock.NewTimer(dsc.opts.Timeout)
 	defer dsc.timer.Stop()
 
-	defer dsc.pass()
+	defer dsc.pass(observe.FlushReasonClosing)
 
 	for {
 		select {
-		case <-dsc.timer.C:
-			dsc.pass()
+		case <-dsc.timer.C():
+			dsc.pass(observe.FlushReasonTimeout)
 		case item, opened := <-dsc.opts.Input:
 			if !opened {
 				return
@@ -162,54 +210,103 @@ func (dsc *Discipline[Type]) loop() {
 }
 
 func (dsc *Discipline[Type]) add(item []Type) {
+	weight := dsc.weigh(item)
+
 	if uint(len(item)) >= dsc.opts.JoinSize {
-		dsc.pass()
-		dsc.forward(item)
+		dsc.pass(observe.FlushReasonSize)
+		dsc.forward(item, observe.FlushReasonSize)
+
+		return
+	}
+
+	if weight >= dsc.opts.MaxWeight {
+		dsc.pass(observe.FlushReasonWeight)
+		dsc.forward(item, observe.FlushReasonWeight)
 
 		return
 	}
 
 	// Integer overflow is impossible because len() function returns only positive
 	// values for the int type and the sum of the two maximum values for the int type is
-	// less than the maximum value for the uint type by one
+	// less than the maximum value for the uint type by one. Also, the accumulated
+	// weight never exceeds the maximum weight
 	if uint(len(item))+uint(len(dsc.join)) > dsc.opts.JoinSize {
-		dsc.pass()
+		dsc.pass(observe.FlushReasonSize)
+	} else if weight > dsc.opts.MaxWeight-dsc.weight {
+		dsc.pass(observe.FlushReasonWeight)
 	}
 
 	dsc.join = append(dsc.join, item...)
 
+	// Integer overflow is impossible because here the weight does not exceed the
+	// free weight
+	dsc.weight += weight
+
 	// Integer overflow is impossible because len() function returns only positive
 	// values for type int and the maximum value for type int is less than the
 	// maximum value for type uint
 	if uint(len(dsc.join)) < dsc.opts.JoinSize {
+		if dsc.weight < dsc.opts.MaxWeight {
+			return
+		}
+
+		dsc.pass(observe.FlushReasonWeight)
+
 		return
 	}
 
-	dsc.pass()
+	dsc.pass(observe.FlushReasonSize)
 }
 
-func (dsc *Discipline[Type]) pass() {
+// Returns the total weight of data items of the input slice limited by the maximum
+// weight.
+func (dsc *Discipline[Type]) weigh(item []Type) uint {
+	if dsc.opts.Weight == nil {
+		return 0
+	}
+
+	weight := uint(0)
+
+	for _, element := range item {
+		// Integer overflow is impossible because the weight is limited by the
+		// maximum weight
+		weight += min(dsc.opts.Weight(element), dsc.opts.MaxWeight-weight)
+
+		if weight == dsc.opts.MaxWeight {
+			return weight
+		}
+	}
+
+	return weight
+}
+
+func (dsc *Discipline[Type]) pass(reason observe.FlushReason) {
 	if len(dsc.join) == 0 {
 		// defer statement is not used to allow inlining of the current function
 		dsc.resetTimer()
 		return
 	}
 
-	dsc.send(dsc.join)
+	dsc.send(dsc.join, reason)
 	dsc.resetJoin()
 	dsc.resetTimer()
 }
 
-func (dsc *Discipline[Type]) forward(item []Type) {
-	dsc.send(item)
+func (dsc *Discipline[Type]) forward(item []Type, reason observe.FlushReason) {
+	dsc.send(item, reason)
 	dsc.resetTimer()
 }
 
-func (dsc *Discipline[Type]) send(item []Type) {
+func (dsc *Discipline[Type]) send(item []Type, reason observe.FlushReason) {
 	item = dsc.prepareItem(item)
 
 	dsc.output <- item
 
+	// Integer overflow is impossible because len() function returns only positive
+	// values for type int and the maximum value for type int is less than the
+	// maximum value for type uint
+	dsc.opts.Observer.OnFlush(reason, uint(len(item)))
+
 	if dsc.opts.NoCopy {
 		<-dsc.release
 	}
@@ -225,6 +322,7 @@ func (dsc *Discipline[Type]) prepareItem(item []Type) []Type {
 
 func (dsc *Discipline[Type]) resetJoin() {
 	dsc.join = dsc.join[:0]
+	dsc.weight = 0
 }
 
 func (dsc *Discipline[Type]) resetTimer() {
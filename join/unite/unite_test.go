@@ -1,11 +1,14 @@
 package unite
 
 import (
+	"math"
 	"slices"
 	"testing"
 	"time"
 
+	"github.com/akramarenkov/flow/clock"
 	"github.com/akramarenkov/flow/join/internal/defaults"
+	"github.com/akramarenkov/flow/observe"
 
 	"github.com/akramarenkov/safe"
 	"github.com/stretchr/testify/require"
@@ -51,6 +54,34 @@ func TestOptsValidation(t *testing.T) {
 
 	_, err = New(opts)
 	require.NoError(t, err)
+
+	opts = Opts[int]{
+		Input:    make(chan []int),
+		JoinSize: 10,
+		Weight:   func(int) uint { return 1 },
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Input:     make(chan []int),
+		JoinSize:  10,
+		MaxWeight: 10,
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Input:     make(chan []int),
+		JoinSize:  10,
+		MaxWeight: 10,
+		Weight:    func(int) uint { return 1 },
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
 }
 
 func TestDiscipline(t *testing.T) {
@@ -231,6 +262,143 @@ func TestDisciplineTimeoutInputNotMultipleJoinSize(t *testing.T) {
 	testDisciplineParallel(t, data, 10, true, timeout, 4, pause, expAt4, durAt4)
 }
 
+func TestDisciplineWeight(t *testing.T) {
+	testDisciplineWeight(t, false)
+	testDisciplineWeight(t, true)
+}
+
+func testDisciplineWeight(t *testing.T, noCopy bool) {
+	// Weight of data item is equal to its value
+	data := [][]uint{
+		{1, 2}, {3, 4}, {10}, {1}, {1}, {5, 7}, {2, 3}, {4, 1}, {1, 1, 1},
+		{1, 1}, {1, 1}, {1}, {math.MaxUint, 1}, {2},
+	}
+
+	expected := [][]uint{
+		{1, 2, 3, 4}, {10}, {1, 1}, {5, 7}, {2, 3, 4, 1}, {1, 1, 1, 1, 1},
+		{1, 1, 1}, {math.MaxUint, 1}, {2},
+	}
+
+	input := make(chan []uint, len(data))
+
+	opts := Opts[uint]{
+		Input:     input,
+		JoinSize:  5,
+		MaxWeight: 10,
+		NoCopy:    noCopy,
+		Weight:    func(item uint) uint { return item },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, item := range data {
+		input <- item
+	}
+
+	close(input)
+
+	output := make([][]uint, 0, len(expected))
+
+	for join := range discipline.Output() {
+		output = append(output, slices.Clone(join))
+		discipline.Release()
+	}
+
+	require.Equal(t, expected, output)
+}
+
+func TestDisciplineObserver(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	input := make(chan []uint)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[uint]{
+		Input:     input,
+		JoinSize:  3,
+		MaxWeight: 10,
+		Observer:  counters,
+		Timeout:   timeout,
+		Weight:    func(item uint) uint { return item },
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- []uint{1, 1, 1}
+
+	require.Equal(t, []uint{1, 1, 1}, <-discipline.Output())
+
+	input <- []uint{9}
+	input <- []uint{2}
+
+	require.Equal(t, []uint{9}, <-discipline.Output())
+	require.Equal(t, []uint{2}, <-discipline.Output())
+
+	input <- []uint{5}
+
+	close(input)
+
+	require.Equal(t, []uint{5}, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonSize))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonWeight))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonTimeout))
+	require.Equal(t, uint64(1), counters.Flushes(observe.FlushReasonClosing))
+	require.Equal(t, uint64(6), counters.FlushedItems())
+}
+
+func TestDisciplineClock(t *testing.T) {
+	const timeout = time.Second
+
+	input := make(chan []int)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock:    manual,
+		Input:    input,
+		JoinSize: 10,
+		Timeout:  timeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	manual.BlockUntil(1)
+
+	input <- []int{1}
+	input <- []int{2, 3}
+
+	manual.Advance(timeout - time.Nanosecond)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, []int{1, 2, 3}, <-discipline.Output())
+
+	// Timeout is counted from the previous writing to the output channel
+	manual.BlockUntil(1)
+
+	input <- []int{4}
+
+	manual.Advance(timeout / 2)
+
+	input <- []int{5, 6}
+
+	manual.Advance(timeout / 2)
+	require.Equal(t, []int{4, 5, 6}, <-discipline.Output())
+
+	close(input)
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
 func TestDisciplineMutable(t *testing.T) {
 	data := [][]int{
 		{},                       // Nothing has been done
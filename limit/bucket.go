@@ -0,0 +1,152 @@
+package limit
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/akramarenkov/safe"
+)
+
+// Token bucket used to limit the speed in the token bucket mode.
+//
+// To avoid loss of accuracy, quantities of tokens are stored scaled by the rate limit
+// interval in nanoseconds. Thus one token corresponds to the Interval value and
+// for each nanosecond the bucket is refilled by the Quantity value.
+type bucket struct {
+	burst     uint64
+	capacity  uint64
+	cost      uint64
+	refill    uint64
+	tokens    uint64
+	updatedAt time.Time
+}
+
+// Creates a full token bucket.
+func newBucket(limit Rate, burst uint64) (*bucket, error) {
+	cost, capacity, err := bucketParams(limit, burst)
+	if err != nil {
+		return nil, err
+	}
+
+	bkt := &bucket{
+		burst:    burst,
+		capacity: capacity,
+		cost:     cost,
+		refill:   limit.Quantity,
+		tokens:   capacity,
+	}
+
+	return bkt, nil
+}
+
+// Calculates scaled cost of one token and scaled capacity of the bucket.
+func bucketParams(limit Rate, burst uint64) (uint64, uint64, error) {
+	cost, err := safe.IToI[uint64](limit.Interval)
+	if err != nil {
+		return 0, 0, fmt.Errorf("calculation of token cost: %w", err)
+	}
+
+	capacity, err := safe.MulU(burst, cost)
+	if err != nil {
+		return 0, 0, fmt.Errorf("calculation of bucket capacity: %w", err)
+	}
+
+	return cost, capacity, nil
+}
+
+// Changes the rate limit of the bucket while keeping the quantity of accumulated
+// tokens.
+//
+// Rate limit must be validated by the [bucketParams] function.
+func (bkt *bucket) setRate(limit Rate, now time.Time) {
+	bkt.fill(now)
+
+	// Error is impossible because the rate limit is validated in advance
+	cost, capacity, _ := bucketParams(limit, bkt.burst)
+
+	// Quotient overflow is impossible because the quantity of accumulated tokens
+	// does not exceed the burst value and the new capacity of the bucket is
+	// representable by the uint64 type
+	hi, lo := bits.Mul64(bkt.tokens, cost)
+	tokens, _ := bits.Div64(hi, lo, bkt.cost)
+
+	bkt.capacity = capacity
+	bkt.cost = cost
+	bkt.refill = limit.Quantity
+	bkt.tokens = tokens
+}
+
+// Returns zero if the specified quantity of tokens is available in the bucket,
+// otherwise returns the duration after which the tokens will be available.
+//
+// Quantity of tokens exceeding the burst value is limited by it, that is, such data
+// item is passed when the bucket is full.
+func (bkt *bucket) delay(now time.Time, cost uint64) time.Duration {
+	bkt.fill(now)
+
+	required := bkt.required(cost)
+
+	if bkt.tokens >= required {
+		return 0
+	}
+
+	// Integer overflow is impossible because here the required quantity is greater
+	// than the quantity of accumulated tokens
+	missing := required - bkt.tokens
+
+	// Rounding up so as not to wake up before the tokens are available. Duration is
+	// limited because the filling of the whole bucket with a large Burst value can
+	// take longer than the maximum value of the time.Duration type
+	return time.Duration(min(bkt.fillingDuration(missing), math.MaxInt64))
+}
+
+// Takes the specified quantity of tokens from the bucket. Tokens must be available,
+// as reported by the [bucket.delay] method.
+func (bkt *bucket) add(now time.Time, cost uint64) {
+	bkt.fill(now)
+
+	// Integer overflow is impossible because the bucket is only refilled after the
+	// tokens were reported as available
+	bkt.tokens -= bkt.required(cost)
+}
+
+// Returns the scaled quantity of tokens required to pass the data item with the
+// specified cost.
+func (bkt *bucket) required(cost uint64) uint64 {
+	// Integer overflow is impossible because the product does not exceed the
+	// capacity of the bucket
+	return min(cost, bkt.burst) * bkt.cost
+}
+
+func (bkt *bucket) fill(now time.Time) {
+	if bkt.updatedAt.IsZero() {
+		bkt.updatedAt = now
+		return
+	}
+
+	// Duration is limited because the time of a clock other than the real one is
+	// not guaranteed to be monotonic
+	elapsed := uint64(max(now.Sub(bkt.updatedAt), 0))
+
+	bkt.updatedAt = now
+
+	missing := bkt.capacity - bkt.tokens
+
+	if elapsed >= bkt.fillingDuration(missing) {
+		bkt.tokens = bkt.capacity
+		return
+	}
+
+	// Integer overflow is impossible because here the elapsed time is less than the
+	// time of filling of the missing tokens and therefore the product is less than
+	// the missing quantity of tokens
+	bkt.tokens += elapsed * bkt.refill
+}
+
+// Returns the duration in nanoseconds, rounded up, for which the specified quantity
+// of tokens is refilled.
+func (bkt *bucket) fillingDuration(quantity uint64) uint64 {
+	return quantity/bkt.refill + min(quantity%bkt.refill, 1)
+}
@@ -0,0 +1,147 @@
+package limit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucket(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 4,
+	}
+
+	bkt, err := newBucket(limit, 2)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	require.Zero(t, bkt.take(now))
+	require.Zero(t, bkt.take(now))
+	require.Equal(t, 250*time.Millisecond, bkt.take(now))
+	require.Equal(t, 150*time.Millisecond, bkt.take(now.Add(100*time.Millisecond)))
+	require.Zero(t, bkt.take(now.Add(250*time.Millisecond)))
+	require.Equal(t, 250*time.Millisecond, bkt.take(now.Add(250*time.Millisecond)))
+
+	// Bucket is refilled no more than to the burst value
+	require.Zero(t, bkt.take(now.Add(time.Hour)))
+	require.Zero(t, bkt.take(now.Add(time.Hour)))
+	require.Equal(t, 250*time.Millisecond, bkt.take(now.Add(time.Hour)))
+}
+
+func TestBucketRoundingUp(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 3,
+	}
+
+	bkt, err := newBucket(limit, 1)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	require.Zero(t, bkt.take(now))
+	require.Equal(t, 333333334*time.Nanosecond, bkt.take(now))
+	require.Equal(t, time.Nanosecond, bkt.take(now.Add(333333333*time.Nanosecond)))
+	require.Zero(t, bkt.take(now.Add(333333334*time.Nanosecond)))
+}
+
+func TestBucketLargeQuantity(t *testing.T) {
+	limit := Rate{
+		Interval: time.Nanosecond,
+		Quantity: math.MaxUint64,
+	}
+
+	bkt, err := newBucket(limit, 1)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	require.Zero(t, bkt.take(now))
+	require.Equal(t, time.Nanosecond, bkt.take(now))
+	require.Zero(t, bkt.take(now.Add(time.Nanosecond)))
+	require.Zero(t, bkt.take(now.Add(time.Hour)))
+}
+
+func TestBucketSetRate(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 4,
+	}
+
+	bkt, err := newBucket(limit, 2)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	require.Zero(t, bkt.take(now))
+	require.Zero(t, bkt.take(now))
+	require.Equal(t, 250*time.Millisecond, bkt.take(now))
+
+	// Half of the token is accumulated
+	now = now.Add(125 * time.Millisecond)
+
+	faster := Rate{
+		Interval: 2 * time.Second,
+		Quantity: 80,
+	}
+
+	bkt.setRate(faster, now)
+	require.Equal(t, 12500*time.Microsecond, bkt.take(now))
+	require.Zero(t, bkt.take(now.Add(12500*time.Microsecond)))
+
+	// Bucket is refilled no more than to the burst value
+	require.Zero(t, bkt.take(now.Add(time.Hour)))
+	require.Zero(t, bkt.take(now.Add(time.Hour)))
+	require.Equal(t, 25*time.Millisecond, bkt.take(now.Add(time.Hour)))
+}
+
+func TestBucketCost(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 4,
+	}
+
+	bkt, err := newBucket(limit, 4)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	require.Zero(t, bkt.delay(now, 3))
+	bkt.add(now, 3)
+
+	require.Equal(t, 250*time.Millisecond, bkt.delay(now, 2))
+
+	// Data item whose cost exceeds the burst value is passed when the bucket is full
+	require.Equal(t, 750*time.Millisecond, bkt.delay(now, 10))
+	require.Zero(t, bkt.delay(now.Add(750*time.Millisecond), 10))
+	bkt.add(now.Add(750*time.Millisecond), 10)
+
+	require.Zero(t, bkt.tokens)
+	require.Equal(t, 250*time.Millisecond, bkt.delay(now.Add(750*time.Millisecond), 1))
+}
+
+func TestBucketError(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 1,
+	}
+
+	_, err := newBucket(limit, math.MaxUint64)
+	require.Error(t, err)
+}
+
+// Takes one token from the bucket if it is available and returns zero, otherwise
+// returns the duration after which the token will be available.
+func (bkt *bucket) take(now time.Time) time.Duration {
+	if delay := bkt.delay(now, 1); delay != 0 {
+		return delay
+	}
+
+	bkt.add(now, 1)
+
+	return 0
+}
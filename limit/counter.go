@@ -0,0 +1,72 @@
+package limit
+
+import "time"
+
+// Counter of units consumed by data items during the current interval, used to limit
+// the speed with several rate limits or with the cost function in the fixed window
+// mode.
+//
+// Interval begins with the first data item passed after the end of the previous
+// one, as well as the transfer of data items in the fixed window mode with one rate
+// limit.
+type counter struct {
+	consumed  uint64
+	interval  time.Duration
+	quantity  uint64
+	startedAt time.Time
+}
+
+// Creates a counter with no units consumed.
+func newCounter(limit Rate) *counter {
+	cnt := &counter{}
+
+	cnt.setRate(limit, time.Time{})
+
+	return cnt
+}
+
+// Changes the rate limit of the counter while keeping the current interval.
+//
+// Rate limit must be validated in advance.
+func (cnt *counter) setRate(limit Rate, _ time.Time) {
+	cnt.interval = limit.Interval
+	cnt.quantity = limit.Quantity
+}
+
+// Returns zero if the data item with the specified cost can be passed, otherwise
+// returns the duration after which the current interval ends.
+func (cnt *counter) delay(now time.Time, cost uint64) time.Duration {
+	if cnt.isExpired(now) || fits(cnt.consumed, cost, cnt.quantity) {
+		return 0
+	}
+
+	// Integer overflow is impossible because the elapsed time of not expired interval
+	// is less than the value of Interval field in rate limit structure
+	return cnt.interval - cnt.elapsed(now)
+}
+
+// Records the passing of the data item with the specified cost.
+func (cnt *counter) add(now time.Time, cost uint64) {
+	if cost == 0 {
+		return
+	}
+
+	if cnt.isExpired(now) {
+		cnt.startedAt = now
+		cnt.consumed = 0
+	}
+
+	// Integer overflow is impossible because the data item is passed only when its
+	// cost fits into the free units or no units are consumed in the current interval
+	cnt.consumed += cost
+}
+
+func (cnt *counter) isExpired(now time.Time) bool {
+	return cnt.startedAt.IsZero() || cnt.elapsed(now) >= cnt.interval
+}
+
+func (cnt *counter) elapsed(now time.Time) time.Duration {
+	// Duration is limited because the time of a clock other than the real one is
+	// not guaranteed to be monotonic
+	return max(now.Sub(cnt.startedAt), 0)
+}
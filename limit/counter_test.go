@@ -0,0 +1,88 @@
+package limit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	cnt := newCounter(limit)
+
+	now := time.Now()
+
+	require.Zero(t, cnt.delay(now, 1))
+	cnt.add(now, 1)
+
+	require.Zero(t, cnt.delay(now.Add(100*time.Millisecond), 1))
+	cnt.add(now.Add(100*time.Millisecond), 1)
+
+	require.Equal(t, 900*time.Millisecond, cnt.delay(now.Add(100*time.Millisecond), 1))
+	require.Equal(t, time.Nanosecond, cnt.delay(now.Add(time.Second-time.Nanosecond), 1))
+
+	// Next interval begins with the first data item passed after the end of the
+	// previous one
+	require.Zero(t, cnt.delay(now.Add(time.Hour), 1))
+	cnt.add(now.Add(time.Hour), 1)
+
+	require.Zero(t, cnt.delay(now.Add(time.Hour), 1))
+	cnt.add(now.Add(time.Hour), 1)
+
+	require.Equal(t, time.Second, cnt.delay(now.Add(time.Hour), 1))
+}
+
+func TestCounterSetRate(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	cnt := newCounter(limit)
+
+	now := time.Now()
+
+	cnt.add(now, 1)
+
+	// Data items passed in the current interval are taken into account with the new
+	// rate limit
+	cnt.setRate(Rate{Interval: 2 * time.Second, Quantity: 1}, now)
+
+	require.Equal(t, 2*time.Second, cnt.delay(now, 1))
+	require.Zero(t, cnt.delay(now.Add(2*time.Second), 1))
+}
+
+func TestCounterCost(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 5,
+	}
+
+	cnt := newCounter(limit)
+
+	now := time.Now()
+
+	require.Zero(t, cnt.delay(now, 3))
+	cnt.add(now, 3)
+
+	require.Zero(t, cnt.delay(now, 2))
+	cnt.add(now, 2)
+
+	require.Equal(t, time.Second, cnt.delay(now, 1))
+
+	// Data item with zero cost does not start the interval
+	cnt.add(now.Add(time.Hour), 0)
+	require.True(t, cnt.isExpired(now.Add(time.Hour)))
+
+	// Data item whose cost exceeds the quantity is passed alone
+	require.Zero(t, cnt.delay(now.Add(time.Hour), 10))
+	cnt.add(now.Add(time.Hour), 10)
+
+	require.Equal(t, time.Second, cnt.delay(now.Add(time.Hour), 1))
+	require.Zero(t, cnt.delay(now.Add(time.Hour+time.Second), 1))
+}
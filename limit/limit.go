@@ -4,15 +4,48 @@ package limit
 
 import (
 	"errors"
+	"sync/atomic"
 	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
 )
 
 var (
-	ErrInputEmpty = errors.New("input channel was not specified")
+	ErrInputEmpty    = errors.New("input channel was not specified")
+	ErrModeAmbiguous = errors.New("token bucket and sliding window modes are both specified")
 )
 
 // Options of the created discipline.
 type Opts[Type any] struct {
+	// Maximum quantity of data items that can be passed at once in the token bucket
+	// mode. A zero value means that the token bucket mode is not used
+	//
+	// In the token bucket mode tokens are refilled continuously with the speed
+	// specified by the rate limit and are accumulated up to the Burst value, one
+	// token is spent on passing one data item. Thus short spikes of data items are
+	// passed at full speed, and the long-run average speed matches the rate limit
+	Burst uint64
+
+	// Source of time used to measure the transfer duration and to perform delays. Is
+	// intended mainly for tests, in which the manual clock allows to advance the time
+	// and to determine exactly when the next data items are passed. If not specified,
+	// the real time is used
+	Clock clock.Clock
+
+	// Function that returns the cost of a data item, that is, the quantity of units
+	// of the rate limit consumed by its passing, for example, the quantity of API
+	// calls performed by a batch write. If not specified, each data item costs one
+	// unit
+	//
+	// Data item whose cost exceeds the Quantity field in the rate limit structure
+	// (the Burst value in the token bucket mode) is not split and is passed alone:
+	// when no units are consumed in the current interval (when the bucket is full),
+	// and then the next data items wait until its cost leaves the interval (is
+	// refilled). Data items with zero cost are passed without waiting and are not
+	// taken into account
+	Cost func(Type) uint64
+
 	// Input channel of data items. For terminate the discipline it is necessary and
 	// sufficient to close the input channel. Preferably input channel should be
 	// buffered for performance reasons. Optimal capacity is in the range of 1e2 to 1e6
@@ -21,11 +54,44 @@ type Opts[Type any] struct {
 	// is closed is a multiple of the Quantity field in the rate limit structure, the
 	// discipline will still perform a delay after the last data item is transmitted.
 	// This, with large values of the Interval field in the rate limit structure, will
-	// result in a long discipline completion time
+	// result in a long discipline completion time. This does not apply to the token
+	// bucket and sliding window modes and to the case of several rate limits or of
+	// the cost function
 	Input <-chan Type
 
-	// Rate limit
+	// Rate limit. Can be changed while the discipline is running by the
+	// [Discipline.SetRate] method
 	Limit Rate
+
+	// Additional rate limits enforced together with the rate limit specified by the
+	// Limit field, for example, per second, per minute and per hour at once. A data
+	// item is passed only when every rate limit allows it. Cannot be changed while
+	// the discipline is running
+	//
+	// In the token bucket mode the Burst value applies to each rate limit. In the
+	// fixed window mode data items are counted separately for each rate limit in
+	// intervals, each of which begins with the first data item passed after the end
+	// of the previous one
+	Limits []Rate
+
+	// Observer of the internal events of the discipline. The discipline reports
+	// pauses in passing of data items by the OnDelay method. If not specified,
+	// events are not reported
+	Observer observe.Observer
+
+	// By default, data items are passed in portions of the Quantity value, after
+	// each of which a delay is performed until the end of the Interval value, so
+	// up to twice the Quantity value can be passed across the boundary of two
+	// intervals. If the Sliding is set to true, then the discipline works in the
+	// sliding window mode, in which no span equal to the Interval value passes more
+	// than the Quantity value of data items. Cannot be specified together with the
+	// Burst
+	//
+	// In the sliding window mode the discipline keeps a log of the times of passing
+	// of data items. If the Quantity value exceeds 1024, passings close in time are
+	// merged in the log, which slightly lowers the achievable speed but keeps the
+	// memory usage bounded
+	Sliding bool
 }
 
 func (opts Opts[Type]) isValid() error {
@@ -33,14 +99,37 @@ func (opts Opts[Type]) isValid() error {
 		return ErrInputEmpty
 	}
 
-	return opts.Limit.IsValid()
+	if opts.Burst != 0 && opts.Sliding {
+		return ErrModeAmbiguous
+	}
+
+	if err := opts.Limit.IsValid(); err != nil {
+		return err
+	}
+
+	for _, limit := range opts.Limits {
+		if err := limit.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (opts Opts[Type]) normalize() Opts[Type] {
+	opts.Clock = clock.Ensure(opts.Clock)
+	opts.Observer = observe.Ensure(opts.Observer)
+
+	return opts
 }
 
 // Limit discipline.
 type Discipline[Type any] struct {
 	opts Opts[Type]
 
-	output chan Type
+	limiters []limiter
+	output   chan Type
+	pending  atomic.Pointer[Rate]
 }
 
 // Creates and runs discipline.
@@ -49,9 +138,18 @@ func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
 		return nil, err
 	}
 
+	opts = opts.normalize()
+
+	limiters, err := newLimiters(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	dsc := &Discipline[Type]{
 		opts: opts,
 
+		limiters: limiters,
+
 		// Value returned by the cap() function is always positive and, in the case of
 		// integer overflow due to adding one, the resulting value can only become
 		// negative, which will cause a panic when executing make() as same as when
@@ -71,14 +169,117 @@ func (dsc *Discipline[Type]) Output() <-chan Type {
 	return dsc.output
 }
 
+// Changes the rate limit of the running discipline specified by the Limit field in
+// options. Additional rate limits are not changed.
+//
+// The new rate limit is validated and then applied at the next transfer boundary:
+// in the token bucket and sliding window modes and in the case of several rate
+// limits or of the cost function before passing the next data item, otherwise
+// after the delay in progress and before passing the next Quantity of data items.
+// Data items are neither lost nor duplicated when the rate limit changes. If this
+// method is called several times before the boundary is reached, then the last
+// rate limit is applied.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Discipline[Type]) SetRate(limit Rate) error {
+	if err := limit.IsValid(); err != nil {
+		return err
+	}
+
+	if dsc.opts.Burst != 0 {
+		if _, _, err := bucketParams(limit, dsc.opts.Burst); err != nil {
+			return err
+		}
+	}
+
+	dsc.pending.Store(&limit)
+
+	return nil
+}
+
+// Applies the rate limit set by the SetRate method, if any. Returns true if the
+// rate limit was changed.
+func (dsc *Discipline[Type]) applyRate() bool {
+	// Loading is performed first to avoid writing to memory shared with the SetRate
+	// method at each transfer boundary
+	if dsc.pending.Load() == nil {
+		return false
+	}
+
+	limit := dsc.pending.Swap(nil)
+
+	dsc.opts.Limit = *limit
+
+	return true
+}
+
 func (dsc *Discipline[Type]) main() {
 	defer close(dsc.output)
 
+	if dsc.limiters != nil {
+		dsc.loopLimiters()
+		return
+	}
+
 	dsc.loop()
 }
 
+func (dsc *Discipline[Type]) loopLimiters() {
+	for item := range dsc.opts.Input {
+		cost := dsc.cost(item)
+
+		if cost == 0 {
+			dsc.send(item)
+			continue
+		}
+
+		dsc.wait(cost)
+		dsc.send(item)
+
+		// Passing is recorded after sending so that the time of writing to the
+		// output channel is taken into account if it was blocked
+		now := dsc.opts.Clock.Now()
+
+		for _, lmt := range dsc.limiters {
+			lmt.add(now, cost)
+		}
+	}
+}
+
+func (dsc *Discipline[Type]) cost(item Type) uint64 {
+	if dsc.opts.Cost == nil {
+		return 1
+	}
+
+	return dsc.opts.Cost(item)
+}
+
+func (dsc *Discipline[Type]) wait(cost uint64) {
+	for {
+		now := dsc.opts.Clock.Now()
+
+		if dsc.applyRate() {
+			dsc.limiters[0].setRate(dsc.opts.Limit, now)
+		}
+
+		delay := time.Duration(0)
+
+		for _, lmt := range dsc.limiters {
+			delay = max(delay, lmt.delay(now, cost))
+		}
+
+		if delay == 0 {
+			return
+		}
+
+		dsc.sleep(delay)
+	}
+}
+
 func (dsc *Discipline[Type]) loop() {
 	for {
+		dsc.applyRate()
+
 		duration, stop := dsc.transfer()
 		if stop {
 			return
@@ -89,15 +290,14 @@ func (dsc *Discipline[Type]) loop() {
 }
 
 func (dsc *Discipline[Type]) transfer() (time.Duration, bool) {
-	startedAt := time.Now()
+	startedAt := dsc.opts.Clock.Now()
 
 	if stop := dsc.pass(); stop {
 		return 0, true
 	}
 
-	// This duration is the time difference of monotonic clock, so it is always
-	// at least non-negative
-	return time.Since(startedAt), false
+	// Duration is limited because a clock other than the real one may go backwards
+	return max(dsc.opts.Clock.Now().Sub(startedAt), 0), false
 }
 
 func (dsc *Discipline[Type]) pass() bool {
@@ -122,5 +322,14 @@ func (dsc *Discipline[Type]) delay(duration time.Duration) {
 	// structure and transfer duration are greater than zero
 	remainder := dsc.opts.Limit.Interval - duration
 
-	time.Sleep(remainder)
+	if remainder <= 0 {
+		return
+	}
+
+	dsc.sleep(remainder)
+}
+
+func (dsc *Discipline[Type]) sleep(duration time.Duration) {
+	dsc.opts.Observer.OnDelay(duration)
+	dsc.opts.Clock.Sleep(duration)
 }
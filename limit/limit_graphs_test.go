@@ -272,7 +272,66 @@ func TestGraphDiscipline(t *testing.T) {
 	)
 }
 
+func TestGraphDisciplineSliding(t *testing.T) {
+	testGraphDisciplineSliding(
+		t,
+		1e4+1,
+		Rate{
+			Interval: time.Second,
+			Quantity: 1e3,
+		},
+		false,
+	)
+
+	testGraphDisciplineSliding(
+		t,
+		1e4+1,
+		Rate{
+			Interval: 100 * time.Millisecond,
+			Quantity: 1e2,
+		},
+		false,
+	)
+
+	testGraphDisciplineSliding(
+		t,
+		1e4+1,
+		Rate{
+			Interval: time.Second,
+			Quantity: 1e3,
+		},
+		true,
+	)
+
+	testGraphDisciplineSliding(
+		t,
+		1e4+1,
+		Rate{
+			Interval: 100 * time.Millisecond,
+			Quantity: 1e2,
+		},
+		true,
+	)
+}
+
 func testGraphDiscipline(t *testing.T, quantity int, limit Rate, stress bool) {
+	opts := Opts[int]{
+		Limit: limit,
+	}
+
+	testGraphDisciplineOpts(t, quantity, opts, stress)
+}
+
+func testGraphDisciplineSliding(t *testing.T, quantity int, limit Rate, stress bool) {
+	opts := Opts[int]{
+		Limit:   limit,
+		Sliding: true,
+	}
+
+	testGraphDisciplineOpts(t, quantity, opts, stress)
+}
+
+func testGraphDisciplineOpts(t *testing.T, quantity int, opts Opts[int], stress bool) {
 	if os.Getenv(env.EnableGraphs) == "" {
 		t.SkipNow()
 	}
@@ -286,10 +345,7 @@ func testGraphDiscipline(t *testing.T, quantity int, limit Rate, stress bool) {
 
 	input := make(chan int, quantity)
 
-	opts := Opts[int]{
-		Input: input,
-		Limit: limit,
-	}
+	opts.Input = input
 
 	discipline, err := New(opts)
 	require.NoError(t, err)
@@ -310,13 +366,14 @@ func testGraphDiscipline(t *testing.T, quantity int, limit Rate, stress bool) {
 		times = append(times, time.Since(startedAt))
 	}
 
-	createQuantitiesGraph(t, times, limit, stress)
+	createQuantitiesGraph(t, times, opts.Limit, opts.Sliding, stress)
 }
 
 func createQuantitiesGraph(
 	t *testing.T,
 	times []time.Duration,
 	limit Rate,
+	sliding bool,
 	stress bool,
 ) {
 	quantities, interval := research.QuantityPerInterval(times, 100, 0)
@@ -329,16 +386,18 @@ func createQuantitiesGraph(
 	expectedDuration := time.Duration(len(times)) * limit.Interval / limitQuantity
 
 	subtitleAdd := fmt.Sprintf(
-		"limit: {quantity: %d, interval: %s}, %s",
+		"limit: {quantity: %d, interval: %s}, sliding: %t, %s",
 		limit.Quantity,
 		limit.Interval,
+		sliding,
 		fmtTotalDuration(expectedDuration, times),
 	)
 
 	fileNameAdd := fmt.Sprintf(
-		"quantities_limit_quantity_%d_limit_interval_%s",
+		"quantities_limit_quantity_%d_limit_interval_%s_sliding_%t",
 		limit.Quantity,
 		limit.Interval,
+		sliding,
 	)
 
 	createGraph(
@@ -1,9 +1,13 @@
 package limit
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
+
 	"github.com/akramarenkov/safe"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +35,99 @@ func TestOptsValidation(t *testing.T) {
 
 	_, err = New(opts)
 	require.NoError(t, err)
+
+	opts = Opts[int]{
+		Burst: math.MaxUint64,
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Burst: 1,
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
+
+	opts = Opts[int]{
+		Burst: 1,
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+		Sliding: true,
+	}
+
+	_, err = New(opts)
+	require.ErrorIs(t, err, ErrModeAmbiguous)
+
+	opts = Opts[int]{
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+		Limits: []Rate{
+			{Interval: time.Minute, Quantity: 10},
+			{Interval: time.Hour},
+		},
+	}
+
+	_, err = New(opts)
+	require.ErrorIs(t, err, ErrQuantityZero)
+
+	opts = Opts[int]{
+		Burst: 1e6,
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+		Limits: []Rate{
+			{Interval: time.Hour * 1e3, Quantity: 1},
+		},
+	}
+
+	_, err = New(opts)
+	require.Error(t, err)
+
+	opts = Opts[int]{
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+		Limits: []Rate{
+			{Interval: time.Minute, Quantity: 10},
+		},
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
+
+	opts = Opts[int]{
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+		Sliding: true,
+	}
+
+	_, err = New(opts)
+	require.NoError(t, err)
 }
 
 func TestDiscipline(t *testing.T) {
@@ -48,10 +145,135 @@ func TestDiscipline(t *testing.T) {
 	require.InEpsilon(t, expected, duration, 0.1)
 }
 
-func testDiscipline(t *testing.T, quantity int, limit Rate) time.Duration {
+func TestDisciplineObserver(t *testing.T) {
+	testDisciplineObserver(t, 0)
+	testDisciplineObserver(t, 1)
+}
+
+func testDisciplineObserver(t *testing.T, burst uint64) {
+	const quantity = 100
+
+	limit := Rate{
+		Interval: 10 * time.Millisecond,
+		Quantity: 10,
+	}
+
+	input := make(chan int, quantity)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[int]{
+		Burst:    burst,
+		Input:    input,
+		Limit:    limit,
+		Observer: counters,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range quantity {
+		input <- item
+	}
+
+	close(input)
+
+	startedAt := time.Now()
+
+	for range discipline.Output() {
+	}
+
+	duration := time.Since(startedAt)
+
+	require.NotZero(t, counters.Delays())
+	require.NotZero(t, counters.DelayDuration())
+	require.Less(t, counters.DelayDuration(), duration+limit.Interval)
+}
+
+func TestDisciplineBurst(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 1000,
+	}
+
+	// Burst of data items is passed without delay
+	duration := testDisciplineBurst(t, 1000, 1000, limit)
+	require.Less(t, duration, limit.Interval/10)
+
+	duration = testDisciplineBurst(t, 10000+1000, 1000, limit)
+	expected := expectedDuration(t, 10000, limit)
+	require.InEpsilon(t, expected, duration, 0.1)
+
+	duration = testDisciplineBurst(t, 10000+10, 10, limit)
+	expected = expectedDuration(t, 10000, limit)
+	require.InEpsilon(t, expected, duration, 0.1)
+}
+
+func TestDisciplineSliding(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 1000,
+	}
+
+	opts := Opts[int]{
+		Limit:   limit,
+		Sliding: true,
+	}
+
+	// First 1000 data items are passed without delay, and each next one is passed
+	// when the data item passed one interval earlier leaves the sliding window
+	duration := testDisciplineOpts(t, 3000+1, opts)
+	expected := expectedDuration(t, 3000, limit)
+	require.InEpsilon(t, expected, duration, 0.1)
+}
+
+func TestDisciplineSetRate(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 1000,
+	}
+
+	faster := Rate{
+		Interval: time.Second,
+		Quantity: 2000,
+	}
+
+	// First 1000 data items are passed with initial rate and the remaining 4000 data
+	// items with the new rate
+	duration := testDisciplineSetRate(t, 5000, 0, limit, 1000, faster)
+	expected := expectedDuration(t, 1000, limit) + expectedDuration(t, 4000, faster)
+	require.InEpsilon(t, expected, duration, 0.1)
+
+	slower := Rate{
+		Interval: time.Second,
+		Quantity: 100,
+	}
+
+	faster = Rate{
+		Interval: time.Second,
+		Quantity: 1000,
+	}
+
+	// First 100 data items are passed without delay due to burst, next 100 data
+	// items are passed with initial rate and the remaining 1000 data items with the
+	// new rate
+	duration = testDisciplineSetRate(t, 100+100+1000, 100, slower, 200, faster)
+	expected = expectedDuration(t, 100, slower) + expectedDuration(t, 1000, faster)
+	require.InEpsilon(t, expected, duration, 0.1)
+}
+
+func testDisciplineSetRate(
+	t *testing.T,
+	quantity int,
+	burst uint64,
+	limit Rate,
+	changeAt int,
+	changed Rate,
+) time.Duration {
 	input := make(chan int, quantity)
 
 	opts := Opts[int]{
+		Burst: burst,
 		Input: input,
 		Limit: limit,
 	}
@@ -59,6 +281,434 @@ func testDiscipline(t *testing.T, quantity int, limit Rate) time.Duration {
 	discipline, err := New(opts)
 	require.NoError(t, err)
 
+	require.Error(t, discipline.SetRate(Rate{}))
+
+	outSequence := make([]int, 0, quantity)
+
+	startedAt := time.Now()
+
+	go func() {
+		defer close(input)
+
+		for item := range quantity {
+			input <- item
+		}
+	}()
+
+	for item := range discipline.Output() {
+		outSequence = append(outSequence, item)
+
+		if len(outSequence) == changeAt {
+			require.NoError(t, discipline.SetRate(changed))
+		}
+	}
+
+	duration := time.Since(startedAt)
+
+	require.Len(t, outSequence, quantity)
+
+	for id, item := range outSequence {
+		require.Equal(t, id, item)
+	}
+
+	return duration
+}
+
+func TestDisciplineSetRateSliding(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 1,
+	}
+
+	faster := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	input := make(chan int, 3)
+
+	for item := range cap(input) {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock:   manual,
+		Input:   input,
+		Limit:   limit,
+		Sliding: true,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-discipline.Output())
+
+	manual.BlockUntil(1)
+
+	require.Error(t, discipline.SetRate(Rate{}))
+	require.NoError(t, discipline.SetRate(faster))
+
+	// New rate limit is applied after the delay in progress, so two data items are
+	// passed at once in the next interval
+	manual.Advance(limit.Interval)
+
+	require.Equal(t, 1, <-discipline.Output())
+	require.Equal(t, 2, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineSetRateError(t *testing.T) {
+	opts := Opts[int]{
+		Burst: 1e6,
+		Input: make(chan int),
+		Limit: Rate{
+			Interval: time.Second,
+			Quantity: 1,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Error(t, discipline.SetRate(Rate{}))
+	require.Error(t, discipline.SetRate(Rate{Interval: time.Hour * 1e3, Quantity: 1}))
+	require.NoError(t, discipline.SetRate(Rate{Interval: time.Hour, Quantity: 1}))
+}
+
+func TestDisciplineClock(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	input := make(chan int, 6)
+
+	for item := range cap(input) {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	counters := &observe.Counters{}
+
+	opts := Opts[int]{
+		Clock:    manual,
+		Input:    input,
+		Limit:    limit,
+		Observer: counters,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for expected := range cap(input) {
+		require.Equal(t, expected, <-discipline.Output())
+
+		if expected%2 == 0 {
+			continue
+		}
+
+		manual.BlockUntil(1)
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(limit.Interval - time.Nanosecond)
+		require.Equal(t, 1, manual.Waiters())
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(time.Nanosecond)
+	}
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+
+	require.Equal(t, uint64(3), counters.Delays())
+	require.Equal(t, 3*limit.Interval, counters.DelayDuration())
+}
+
+func TestDisciplineClockBurst(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	input := make(chan int, 4)
+
+	for item := range cap(input) {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Burst: 2,
+		Clock: manual,
+		Input: input,
+		Limit: limit,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	// Burst of data items is passed without delay
+	require.Equal(t, 0, <-discipline.Output())
+	require.Equal(t, 1, <-discipline.Output())
+
+	for expected := 2; expected < cap(input); expected++ {
+		manual.BlockUntil(1)
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(limit.Interval/2 - time.Nanosecond)
+		require.Equal(t, 1, manual.Waiters())
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(time.Nanosecond)
+		require.Equal(t, expected, <-discipline.Output())
+	}
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineClockSliding(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	input := make(chan int)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock:   manual,
+		Input:   input,
+		Limit:   limit,
+		Sliding: true,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 0
+	require.Equal(t, 0, <-discipline.Output())
+
+	manual.Advance(limit.Interval / 2)
+
+	input <- 1
+	require.Equal(t, 1, <-discipline.Output())
+
+	// Each next data item is passed only when the data item passed one interval
+	// earlier leaves the sliding window
+	for expected := 2; expected < 6; expected++ {
+		input <- expected
+
+		manual.BlockUntil(1)
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(limit.Interval/2 - time.Nanosecond)
+		require.Equal(t, 1, manual.Waiters())
+		require.Empty(t, discipline.Output())
+
+		manual.Advance(time.Nanosecond)
+		require.Equal(t, expected, <-discipline.Output())
+	}
+
+	close(input)
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineLimits(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 3,
+	}
+
+	input := make(chan int, 6)
+
+	for item := range cap(input) {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	counters := &observe.Counters{}
+
+	opts := Opts[int]{
+		Clock: manual,
+		Input: input,
+		Limit: limit,
+		Limits: []Rate{
+			{Interval: 3 * time.Second, Quantity: 4},
+		},
+		Observer: counters,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-discipline.Output())
+	require.Equal(t, 1, <-discipline.Output())
+	require.Equal(t, 2, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Second)
+	require.Equal(t, 3, <-discipline.Output())
+
+	// First rate limit allows passing, but the second one is exhausted until the end
+	// of its interval
+	manual.BlockUntil(1)
+	manual.Advance(2*time.Second - time.Nanosecond)
+	require.Equal(t, 1, manual.Waiters())
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, 4, <-discipline.Output())
+	require.Equal(t, 5, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+
+	require.Equal(t, uint64(2), counters.Delays())
+	require.Equal(t, 3*time.Second, counters.DelayDuration())
+}
+
+func TestDisciplineLimitsSliding(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	input := make(chan int, 5)
+
+	for item := range cap(input) {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock: manual,
+		Input: input,
+		Limit: limit,
+		Limits: []Rate{
+			{Interval: 3 * time.Second, Quantity: 3},
+		},
+		Sliding: true,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-discipline.Output())
+	require.Equal(t, 1, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	manual.Advance(time.Second)
+	require.Equal(t, 2, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	manual.Advance(2*time.Second - time.Nanosecond)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(time.Nanosecond)
+	require.Equal(t, 3, <-discipline.Output())
+	require.Equal(t, 4, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func TestDisciplineCost(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 5,
+	}
+
+	data := []int{3, 2, 4, 7, 0, 1}
+
+	input := make(chan int, len(data))
+
+	for _, item := range data {
+		input <- item
+	}
+
+	close(input)
+
+	manual := clock.NewManual(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := Opts[int]{
+		Clock: manual,
+		Cost:  func(item int) uint64 { return uint64(item) },
+		Input: input,
+		Limit: limit,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, <-discipline.Output())
+	require.Equal(t, 2, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(limit.Interval)
+	require.Equal(t, 4, <-discipline.Output())
+
+	// Data item whose cost exceeds the quantity waits for the next interval and is
+	// passed alone, while the data item with zero cost is passed without waiting
+	manual.BlockUntil(1)
+	manual.Advance(limit.Interval)
+	require.Equal(t, 7, <-discipline.Output())
+	require.Equal(t, 0, <-discipline.Output())
+
+	manual.BlockUntil(1)
+	require.Empty(t, discipline.Output())
+
+	manual.Advance(limit.Interval)
+	require.Equal(t, 1, <-discipline.Output())
+
+	_, opened := <-discipline.Output()
+	require.False(t, opened)
+}
+
+func testDiscipline(t *testing.T, quantity int, limit Rate) time.Duration {
+	return testDisciplineBurst(t, quantity, 0, limit)
+}
+
+func testDisciplineBurst(t *testing.T, quantity int, burst uint64, limit Rate) time.Duration {
+	opts := Opts[int]{
+		Burst: burst,
+		Limit: limit,
+	}
+
+	return testDisciplineOpts(t, quantity, opts)
+}
+
+func testDisciplineOpts(t *testing.T, quantity int, opts Opts[int]) time.Duration {
+	input := make(chan int, quantity)
+
+	opts.Input = input
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
 	inpSequence := make([]int, 0, quantity)
 	outSequence := make([]int, 0, quantity)
 
@@ -222,3 +872,39 @@ func BenchmarkDiscipline(b *testing.B) {
 		_ = item
 	}
 }
+
+// Here we model the worst case for the token bucket mode: when the bucket is always
+// empty and the delay is calculated for each data item.
+func BenchmarkDisciplineBurst(b *testing.B) {
+	quantity := b.N
+
+	limit := Rate{
+		Interval: time.Nanosecond,
+		Quantity: 1,
+	}
+
+	input := make(chan int, quantity)
+
+	opts := Opts[int]{
+		Burst: 1,
+		Input: input,
+		Limit: limit,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	go func() {
+		defer close(input)
+
+		for item := range quantity {
+			input <- item
+		}
+	}()
+
+	for item := range discipline.Output() {
+		_ = item
+	}
+}
@@ -0,0 +1,60 @@
+package limit
+
+import "time"
+
+// Limiter of the speed of passing data items, used in all modes except the fixed
+// window mode with one rate limit.
+type limiter interface {
+	// Returns zero if the data item with the specified cost can be passed, otherwise
+	// returns the duration after which it can be passed
+	delay(now time.Time, cost uint64) time.Duration
+
+	// Records the passing of the data item with the specified cost
+	add(now time.Time, cost uint64)
+
+	// Changes the rate limit, which must be validated in advance
+	setRate(limit Rate, now time.Time)
+}
+
+// Creates limiters for the rate limit and additional rate limits according to the
+// mode of the discipline. Returns nil if the fixed window mode with one rate limit
+// and without the cost function is used.
+func newLimiters[Type any](opts Opts[Type]) ([]limiter, error) {
+	if opts.Burst == 0 && !opts.Sliding && len(opts.Limits) == 0 && opts.Cost == nil {
+		return nil, nil
+	}
+
+	limiters := make([]limiter, 0, 1+len(opts.Limits))
+
+	for _, limit := range append([]Rate{opts.Limit}, opts.Limits...) {
+		switch {
+		case opts.Burst != 0:
+			bkt, err := newBucket(limit, opts.Burst)
+			if err != nil {
+				return nil, err
+			}
+
+			limiters = append(limiters, bkt)
+		case opts.Sliding:
+			limiters = append(limiters, newWindow(limit))
+		default:
+			limiters = append(limiters, newCounter(limit))
+		}
+	}
+
+	return limiters, nil
+}
+
+// Checks whether the data item with the specified cost can be passed when the
+// specified quantity of units is consumed. Data item whose cost exceeds the free
+// units, including the entire quantity of units, is passed only alone, when no units
+// are consumed.
+func fits(consumed uint64, cost uint64, quantity uint64) bool {
+	if consumed == 0 {
+		return true
+	}
+
+	// Integer overflow is impossible because the subtraction is performed only when
+	// the consumed units are less than the quantity
+	return consumed < quantity && cost <= quantity-consumed
+}
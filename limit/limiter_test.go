@@ -0,0 +1,21 @@
+package limit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFits(t *testing.T) {
+	require.True(t, fits(0, 1, 5))
+	require.True(t, fits(0, 5, 5))
+	require.True(t, fits(0, 6, 5))
+	require.True(t, fits(0, math.MaxUint64, 5))
+	require.True(t, fits(3, 2, 5))
+	require.True(t, fits(3, 0, 5))
+	require.False(t, fits(3, 3, 5))
+	require.False(t, fits(5, 1, 5))
+	require.False(t, fits(6, 0, 5))
+	require.False(t, fits(3, math.MaxUint64, 5))
+}
@@ -0,0 +1,123 @@
+package limit
+
+import "time"
+
+// Maximum quantity of entries in the log of the sliding window, if the Quantity
+// field in rate limit structure exceeds it, then passings of data items close in
+// time are merged into one entry.
+const windowLength = 1 << 10
+
+// Log of passings of data items used to limit the speed in the sliding window mode.
+//
+// Each entry of the log is kept until the Interval value has elapsed since the last
+// passing merged into it, so the entries cover all data items passed during any
+// span equal to the Interval value. Merging of passings only extends the lifetime of
+// the entries, therefore the Quantity value is never exceeded.
+type window struct {
+	consumed   uint64
+	entries    []passing
+	interval   time.Duration
+	openedAt   time.Time
+	quantity   uint64
+	resolution time.Duration
+}
+
+type passing struct {
+	at       time.Time
+	quantity uint64
+}
+
+// Creates an empty log of the sliding window.
+func newWindow(limit Rate) *window {
+	wnd := &window{}
+
+	wnd.setRate(limit, time.Time{})
+
+	return wnd
+}
+
+// Changes the rate limit of the log while keeping its entries.
+//
+// Rate limit must be validated in advance.
+func (wnd *window) setRate(limit Rate, _ time.Time) {
+	wnd.interval = limit.Interval
+	wnd.quantity = limit.Quantity
+	wnd.resolution = 0
+
+	if limit.Quantity > windowLength {
+		// Resolution is not less than one nanosecond so that the quantity of entries
+		// does not exceed the window length even with the clock standing still
+		wnd.resolution = max(limit.Interval/windowLength, time.Nanosecond)
+	}
+}
+
+// Returns zero if the data item with the specified cost can be passed, otherwise
+// returns the duration after which enough entries of the log expire.
+func (wnd *window) delay(now time.Time, cost uint64) time.Duration {
+	wnd.expire(now)
+
+	if fits(wnd.consumed, cost, wnd.quantity) {
+		return 0
+	}
+
+	consumed := wnd.consumed
+
+	for _, entry := range wnd.entries {
+		consumed -= entry.quantity
+
+		if fits(consumed, cost, wnd.quantity) {
+			// Integer overflow is impossible because the elapsed time of not expired
+			// entry is less than the value of Interval field in rate limit structure
+			return wnd.interval - wnd.elapsed(entry, now)
+		}
+	}
+
+	// Is unreachable because the consumed units are the sum of units of the entries
+	// and the data item always fits into the empty log
+	return wnd.interval
+}
+
+// Records the passing of the data item with the specified cost.
+func (wnd *window) add(now time.Time, cost uint64) {
+	if cost == 0 {
+		return
+	}
+
+	// Integer overflow is impossible because the data item is passed only when its
+	// cost fits into the free units or the log is empty
+	wnd.consumed += cost
+
+	if len(wnd.entries) != 0 && now.Sub(wnd.openedAt) < wnd.resolution {
+		newest := &wnd.entries[len(wnd.entries)-1]
+
+		newest.quantity += cost
+
+		if now.After(newest.at) {
+			newest.at = now
+		}
+
+		return
+	}
+
+	wnd.entries = append(wnd.entries, passing{at: now, quantity: cost})
+	wnd.openedAt = now
+}
+
+func (wnd *window) expire(now time.Time) {
+	for len(wnd.entries) != 0 {
+		oldest := wnd.entries[0]
+
+		if wnd.elapsed(oldest, now) < wnd.interval {
+			return
+		}
+
+		wnd.consumed -= oldest.quantity
+		wnd.entries = wnd.entries[1:]
+	}
+}
+
+func (*window) elapsed(entry passing, now time.Time) time.Duration {
+	// Duration is limited because the time of a clock other than the real one is
+	// not guaranteed to be monotonic
+	return max(now.Sub(entry.at), 0)
+}
@@ -0,0 +1,205 @@
+package limit
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/limit/internal/research"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	wnd := newWindow(limit)
+
+	now := time.Now()
+
+	require.Zero(t, wnd.delay(now, 1))
+	wnd.add(now, 1)
+
+	require.Zero(t, wnd.delay(now.Add(100*time.Millisecond), 1))
+	wnd.add(now.Add(100*time.Millisecond), 1)
+
+	require.Equal(t, 900*time.Millisecond, wnd.delay(now.Add(100*time.Millisecond), 1))
+	require.Equal(t, time.Nanosecond, wnd.delay(now.Add(time.Second-time.Nanosecond), 1))
+
+	require.Zero(t, wnd.delay(now.Add(time.Second), 1))
+	wnd.add(now.Add(time.Second), 1)
+
+	require.Equal(t, 100*time.Millisecond, wnd.delay(now.Add(time.Second), 1))
+
+	// Log is devastated after the interval has elapsed since the last passing
+	require.Zero(t, wnd.delay(now.Add(time.Hour), 1))
+	require.Empty(t, wnd.entries)
+	require.Zero(t, wnd.consumed)
+}
+
+func TestWindowMerging(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 4 * windowLength,
+	}
+
+	wnd := newWindow(limit)
+
+	now := time.Now()
+
+	for range limit.Quantity {
+		require.Zero(t, wnd.delay(now, 1))
+		wnd.add(now, 1)
+	}
+
+	require.Len(t, wnd.entries, 1)
+	require.Equal(t, limit.Interval, wnd.delay(now, 1))
+
+	// Passings close in time are merged, and lifetime of the entry is counted from
+	// the last passing merged into it
+	later := now.Add(time.Hour)
+
+	for id := range limit.Quantity {
+		moment := later.Add(time.Duration(id) * time.Microsecond)
+
+		require.Zero(t, wnd.delay(moment, 1))
+		wnd.add(moment, 1)
+	}
+
+	last := later.Add(time.Duration(limit.Quantity-1) * time.Microsecond)
+
+	require.LessOrEqual(t, len(wnd.entries), windowLength+1)
+	require.True(t, wnd.entries[0].at.After(later))
+	require.Equal(t, wnd.entries[0].at.Add(limit.Interval), last.Add(wnd.delay(last, 1)))
+}
+
+func TestWindowSetRate(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 2,
+	}
+
+	wnd := newWindow(limit)
+
+	now := time.Now()
+
+	wnd.add(now, 1)
+	wnd.add(now.Add(100*time.Millisecond), 1)
+
+	// Entries of the log are kept, so the passed data items are taken into account
+	// with the new rate limit
+	wnd.setRate(Rate{Interval: time.Second, Quantity: 1}, now)
+
+	require.Equal(t, 100*time.Millisecond, wnd.delay(now.Add(time.Second), 1))
+	require.Zero(t, wnd.delay(now.Add(time.Second+100*time.Millisecond), 1))
+
+	wnd.setRate(Rate{Interval: time.Second, Quantity: 3}, now)
+
+	wnd.add(now.Add(time.Hour), 1)
+	wnd.add(now.Add(time.Hour), 1)
+	require.Zero(t, wnd.delay(now.Add(time.Hour), 1))
+}
+
+func TestWindowAccuracy(t *testing.T) {
+	testWindowAccuracy(t, Rate{Interval: time.Second, Quantity: 10})
+	testWindowAccuracy(t, Rate{Interval: 10 * time.Millisecond, Quantity: 100})
+	testWindowAccuracy(t, Rate{Interval: time.Second, Quantity: 3 * windowLength})
+}
+
+// Simulates the passing of data items, which arrive irregularly, with delays that
+// are oversleeping, and checks that no span equal to the interval contains more than
+// the quantity of data items.
+func testWindowAccuracy(t *testing.T, limit Rate) {
+	const intervals = 20
+
+	// Constant seed is used for reproducibility of the test
+	random := rand.New(rand.NewPCG(1, 2))
+
+	wnd := newWindow(limit)
+
+	startedAt := time.Now()
+	now := startedAt
+
+	times := make([]time.Duration, 0)
+
+	for now.Sub(startedAt) < intervals*limit.Interval {
+		for {
+			delay := wnd.delay(now, 1)
+			if delay == 0 {
+				break
+			}
+
+			now = now.Add(delay + time.Duration(random.Int64N(int64(delay/10+1))))
+		}
+
+		wnd.add(now, 1)
+
+		times = append(times, now.Sub(startedAt))
+
+		// Pauses in arrival of data items occur about once per interval
+		if random.Uint64N(limit.Quantity) == 0 {
+			now = now.Add(time.Duration(random.Int64N(int64(limit.Interval / 2))))
+		}
+	}
+
+	// Spans of quantities are shifted in order to check different positions of the
+	// window relative to the passings
+	const shifts = 100
+
+	maximum := uint64(0)
+
+	for shift := range time.Duration(shifts) {
+		shifted := make([]time.Duration, len(times))
+
+		for id, moment := range times {
+			shifted[id] = moment + shift*limit.Interval/shifts
+		}
+
+		quantities, _ := research.QuantityPerInterval(shifted, 0, limit.Interval)
+
+		for _, quantity := range quantities {
+			require.LessOrEqual(t, uint64(quantity.Quantity), limit.Quantity)
+
+			maximum = max(maximum, uint64(quantity.Quantity))
+		}
+	}
+
+	// Sliding window keeps the speed close to the rate limit
+	require.Equal(t, limit.Quantity, maximum)
+}
+
+func TestWindowCost(t *testing.T) {
+	limit := Rate{
+		Interval: time.Second,
+		Quantity: 5,
+	}
+
+	wnd := newWindow(limit)
+
+	now := time.Now()
+
+	require.Zero(t, wnd.delay(now, 3))
+	wnd.add(now, 3)
+
+	require.Zero(t, wnd.delay(now.Add(100*time.Millisecond), 2))
+	wnd.add(now.Add(100*time.Millisecond), 2)
+
+	// Waiting for as many entries to expire as needed to free units for the cost
+	require.Equal(t, 900*time.Millisecond, wnd.delay(now.Add(100*time.Millisecond), 3))
+	require.Equal(t, time.Second, wnd.delay(now.Add(100*time.Millisecond), 4))
+
+	// Data item with zero cost is not recorded
+	wnd.add(now.Add(time.Hour), 0)
+	require.Len(t, wnd.entries, 2)
+	require.Equal(t, uint64(5), wnd.consumed)
+
+	// Data item whose cost exceeds the quantity is passed alone
+	require.Zero(t, wnd.delay(now.Add(time.Hour), 10))
+	wnd.add(now.Add(time.Hour), 10)
+
+	require.Equal(t, time.Second, wnd.delay(now.Add(time.Hour), 1))
+	require.Zero(t, wnd.delay(now.Add(time.Hour+time.Second), 1))
+}
@@ -0,0 +1,161 @@
+package observe
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Observer that counts events using atomic counters. Values of the counters can be
+// read at any time from any goroutine, for example, to export them as metrics.
+//
+// Zero value is ready to use. Must not be copied after first use.
+type Counters struct {
+	delayDuration atomic.Int64
+	delays        atomic.Uint64
+	dividerErrors atomic.Uint64
+	flushedItems  atomic.Uint64
+	flushes       [flushReasonsQuantity]atomic.Uint64
+
+	mutex      sync.RWMutex
+	priorities map[uint]*priorityCounters
+}
+
+type priorityCounters struct {
+	dispatched atomic.Uint64
+	reclaimed  atomic.Uint64
+	released   atomic.Uint64
+}
+
+func (cnt *Counters) OnFlush(reason FlushReason, size uint) {
+	if reason.isValid() {
+		cnt.flushes[reason-1].Add(1)
+	}
+
+	cnt.flushedItems.Add(uint64(size))
+}
+
+func (cnt *Counters) OnDelay(delay time.Duration) {
+	cnt.delays.Add(1)
+	cnt.delayDuration.Add(int64(delay))
+}
+
+func (cnt *Counters) OnDispatch(priority uint) {
+	cnt.priority(priority).dispatched.Add(1)
+}
+
+func (cnt *Counters) OnRelease(priority uint) {
+	cnt.priority(priority).released.Add(1)
+}
+
+func (cnt *Counters) OnReclaim(priority uint) {
+	cnt.priority(priority).reclaimed.Add(1)
+}
+
+func (cnt *Counters) OnDividerError(error) {
+	cnt.dividerErrors.Add(1)
+}
+
+// Returns the quantity of written slices for the specified flush reason.
+func (cnt *Counters) Flushes(reason FlushReason) uint64 {
+	if !reason.isValid() {
+		return 0
+	}
+
+	return cnt.flushes[reason-1].Load()
+}
+
+// Returns the total quantity of data items in the written slices.
+func (cnt *Counters) FlushedItems() uint64 {
+	return cnt.flushedItems.Load()
+}
+
+// Returns the quantity of delays.
+func (cnt *Counters) Delays() uint64 {
+	return cnt.delays.Load()
+}
+
+// Returns the total duration of delays.
+func (cnt *Counters) DelayDuration() time.Duration {
+	return time.Duration(cnt.delayDuration.Load())
+}
+
+// Returns the quantity of errors of the divider.
+func (cnt *Counters) DividerErrors() uint64 {
+	return cnt.dividerErrors.Load()
+}
+
+// Returns the quantity of data items of the specified priority written to the
+// output channel.
+func (cnt *Counters) Dispatched(priority uint) uint64 {
+	if counters := cnt.lookup(priority); counters != nil {
+		return counters.dispatched.Load()
+	}
+
+	return 0
+}
+
+// Returns the quantity of released data items of the specified priority.
+func (cnt *Counters) Released(priority uint) uint64 {
+	if counters := cnt.lookup(priority); counters != nil {
+		return counters.released.Load()
+	}
+
+	return 0
+}
+
+// Returns the quantity of reclaimed leases of data items of the specified priority.
+func (cnt *Counters) Reclaimed(priority uint) uint64 {
+	if counters := cnt.lookup(priority); counters != nil {
+		return counters.reclaimed.Load()
+	}
+
+	return 0
+}
+
+// Returns the sorted list of priorities for which events have been observed.
+func (cnt *Counters) Priorities() []uint {
+	cnt.mutex.RLock()
+	defer cnt.mutex.RUnlock()
+
+	priorities := make([]uint, 0, len(cnt.priorities))
+
+	for priority := range cnt.priorities {
+		priorities = append(priorities, priority)
+	}
+
+	slices.Sort(priorities)
+
+	return priorities
+}
+
+func (cnt *Counters) lookup(priority uint) *priorityCounters {
+	cnt.mutex.RLock()
+	defer cnt.mutex.RUnlock()
+
+	return cnt.priorities[priority]
+}
+
+func (cnt *Counters) priority(priority uint) *priorityCounters {
+	if counters := cnt.lookup(priority); counters != nil {
+		return counters
+	}
+
+	cnt.mutex.Lock()
+	defer cnt.mutex.Unlock()
+
+	if counters, exists := cnt.priorities[priority]; exists {
+		return counters
+	}
+
+	if cnt.priorities == nil {
+		cnt.priorities = make(map[uint]*priorityCounters)
+	}
+
+	counters := &priorityCounters{}
+
+	cnt.priorities[priority] = counters
+
+	return counters
+}
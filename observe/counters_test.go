@@ -0,0 +1,94 @@
+package observe
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounters(t *testing.T) {
+	counters := &Counters{}
+
+	counters.OnFlush(FlushReasonSize, 10)
+	counters.OnFlush(FlushReasonSize, 10)
+	counters.OnFlush(FlushReasonTimeout, 3)
+	counters.OnFlush(FlushReason(0), 1)
+
+	require.Equal(t, uint64(2), counters.Flushes(FlushReasonSize))
+	require.Equal(t, uint64(1), counters.Flushes(FlushReasonTimeout))
+	require.Equal(t, uint64(0), counters.Flushes(FlushReasonClosing))
+	require.Equal(t, uint64(0), counters.Flushes(FlushReason(0)))
+	require.Equal(t, uint64(24), counters.FlushedItems())
+
+	counters.OnDelay(time.Second)
+	counters.OnDelay(time.Millisecond)
+
+	require.Equal(t, uint64(2), counters.Delays())
+	require.Equal(t, time.Second+time.Millisecond, counters.DelayDuration())
+
+	counters.OnDividerError(errors.New("divider error"))
+
+	require.Equal(t, uint64(1), counters.DividerErrors())
+
+	counters.OnDispatch(3)
+	counters.OnDispatch(1)
+	counters.OnDispatch(1)
+	counters.OnRelease(1)
+	counters.OnReclaim(3)
+
+	require.Equal(t, []uint{1, 3}, counters.Priorities())
+	require.Equal(t, uint64(1), counters.Reclaimed(3))
+	require.Equal(t, uint64(0), counters.Reclaimed(1))
+	require.Equal(t, uint64(0), counters.Reclaimed(2))
+	require.Equal(t, uint64(2), counters.Dispatched(1))
+	require.Equal(t, uint64(1), counters.Dispatched(3))
+	require.Equal(t, uint64(0), counters.Dispatched(2))
+	require.Equal(t, uint64(1), counters.Released(1))
+	require.Equal(t, uint64(0), counters.Released(3))
+	require.Equal(t, uint64(0), counters.Released(2))
+}
+
+func TestCountersParallel(t *testing.T) {
+	const (
+		goroutines = 10
+		quantity   = 1000
+	)
+
+	counters := &Counters{}
+
+	var wg sync.WaitGroup
+
+	for range goroutines {
+		wg.Go(func() {
+			for priority := range uint(quantity) {
+				counters.OnDispatch(priority % 3)
+				counters.OnRelease(priority % 3)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	require.Equal(t, []uint{0, 1, 2}, counters.Priorities())
+
+	total := uint64(0)
+
+	for _, priority := range counters.Priorities() {
+		require.Equal(t, counters.Dispatched(priority), counters.Released(priority))
+
+		total += counters.Dispatched(priority)
+	}
+
+	require.Equal(t, uint64(goroutines*quantity), total)
+}
+
+func BenchmarkCountersOnDispatch(b *testing.B) {
+	counters := &Counters{}
+
+	for range b.N {
+		counters.OnDispatch(1)
+	}
+}
@@ -0,0 +1,150 @@
+// Interface of an observer of the disciplines and its implementation based on atomic
+// counters.
+package observe
+
+import (
+	"time"
+)
+
+// Reason of writing the accumulated slice to the output channel.
+type FlushReason int
+
+const (
+	// Maximum size of the accumulated slice is reached.
+	FlushReasonSize FlushReason = iota + 1
+	// Maximum weight of the accumulated slice is reached.
+	FlushReasonWeight
+	// Timeout for accumulation of the slice is expired.
+	FlushReasonTimeout
+	// Input channel is closed.
+	FlushReasonClosing
+	// Slice is evicted to free up space for a slice of a new key.
+	FlushReasonEviction
+	// Context of the discipline is canceled.
+	FlushReasonCancellation
+)
+
+// Quantity of flush reasons.
+const flushReasonsQuantity = int(FlushReasonCancellation)
+
+// Returns the name of the flush reason.
+func (reason FlushReason) String() string {
+	switch reason {
+	case FlushReasonSize:
+		return "size"
+	case FlushReasonWeight:
+		return "weight"
+	case FlushReasonTimeout:
+		return "timeout"
+	case FlushReasonClosing:
+		return "closing"
+	case FlushReasonEviction:
+		return "eviction"
+	case FlushReasonCancellation:
+		return "cancellation"
+	}
+
+	return "unknown"
+}
+
+func (reason FlushReason) isValid() bool {
+	return reason >= FlushReasonSize && int(reason) <= flushReasonsQuantity
+}
+
+// Observer of the internal events of the disciplines.
+//
+// Methods are called synchronously from the goroutines of the disciplines, so they
+// must be fast and must not block. Each discipline calls only the methods related
+// to it, the rest can be implemented by embedding the [Nop] type.
+type Observer interface {
+	// Is called by the join disciplines when the accumulated slice of the specified
+	// size is written to the output channel
+	OnFlush(reason FlushReason, size uint)
+
+	// Is called by the limit discipline before it pauses passing of data items for
+	// the specified duration
+	OnDelay(delay time.Duration)
+
+	// Is called by the priority disciplines when a data item of the specified
+	// priority is written to the output channel
+	OnDispatch(priority uint)
+
+	// Is called by the priority disciplines when a release of a data item of the
+	// specified priority is received
+	OnRelease(priority uint)
+
+	// Is called by the priority disciplines when the divider returns an error or
+	// creates an incorrect distribution
+	OnDividerError(err error)
+}
+
+// Observer that does nothing. Is used by the disciplines when an observer is not
+// specified.
+type Nop struct{}
+
+func (Nop) OnFlush(FlushReason, uint) {}
+
+func (Nop) OnDelay(time.Duration) {}
+
+func (Nop) OnDispatch(uint) {}
+
+func (Nop) OnRelease(uint) {}
+
+func (Nop) OnDividerError(error) {}
+
+// Observer of the internal events of the priority disciplines with priorities of
+// any type.
+//
+// It is a subset of the [Observer] interface methods related to the priority
+// disciplines, so any [Observer] is also a PriorityObserver for priorities of uint
+// type.
+type PriorityObserver[Key any] interface {
+	// Is called when a data item of the specified priority is written to the output
+	// channel
+	OnDispatch(priority Key)
+
+	// Is called when a release of a data item of the specified priority is received
+	OnRelease(priority Key)
+
+	// Is called when the divider returns an error or creates an incorrect
+	// distribution
+	OnDividerError(err error)
+}
+
+// Optional interface of an observer of the priority disciplines. If the observer
+// passed to the priority discipline also implements this interface, then it is
+// notified of the reclamations of leases.
+type ReclaimObserver[Key any] interface {
+	// Is called when a lease of a data item of the specified priority is reclaimed
+	// because its processing timeout has expired
+	OnReclaim(priority Key)
+}
+
+// Priority observer that does nothing. Is used by the priority disciplines when an
+// observer is not specified.
+type PriorityNop[Key any] struct{}
+
+func (PriorityNop[Key]) OnDispatch(Key) {}
+
+func (PriorityNop[Key]) OnRelease(Key) {}
+
+func (PriorityNop[Key]) OnDividerError(error) {}
+
+// Returns the specified observer or the [Nop] observer if it is not specified.
+func Ensure(observer Observer) Observer {
+	if observer == nil {
+		return Nop{}
+	}
+
+	return observer
+}
+
+// Returns the specified priority observer or the [PriorityNop] observer if it is
+// not specified.
+func EnsurePriority[Key any](observer PriorityObserver[Key]) PriorityObserver[Key] {
+	if observer == nil {
+		return PriorityNop[Key]{}
+	}
+
+	return observer
+}
@@ -0,0 +1,31 @@
+package observe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushReasonString(t *testing.T) {
+	require.Equal(t, "size", FlushReasonSize.String())
+	require.Equal(t, "weight", FlushReasonWeight.String())
+	require.Equal(t, "timeout", FlushReasonTimeout.String())
+	require.Equal(t, "closing", FlushReasonClosing.String())
+	require.Equal(t, "eviction", FlushReasonEviction.String())
+	require.Equal(t, "cancellation", FlushReasonCancellation.String())
+	require.Equal(t, "unknown", FlushReason(0).String())
+	require.Equal(t, "unknown", FlushReason(flushReasonsQuantity+1).String())
+}
+
+func TestEnsure(t *testing.T) {
+	require.Equal(t, Nop{}, Ensure(nil))
+
+	counters := &Counters{}
+	require.Same(t, counters, Ensure(counters))
+}
+
+func TestReclaimObserver(t *testing.T) {
+	require.Implements(t, (*ReclaimObserver[uint])(nil), &Counters{})
+	require.NotImplements(t, (*ReclaimObserver[uint])(nil), Nop{})
+	require.NotImplements(t, (*ReclaimObserver[string])(nil), PriorityNop[string]{})
+}
@@ -2,7 +2,13 @@ package priority
 
 import "cmp"
 
-// Compare function for sorting priorities.
-func Compare(first, second uint) int {
+// Compare function for sorting priorities in descending order.
+func Compare[Key cmp.Ordered](first, second Key) int {
 	return cmp.Compare(second, first)
 }
+
+func isZero[Key comparable](priority Key) bool {
+	var zero Key
+
+	return priority == zero
+}
@@ -5,11 +5,11 @@ import (
 	"github.com/akramarenkov/flow/priority/priodefs"
 )
 
-func divide(
-	divider priodefs.Divider,
+func divide[Key comparable](
+	divider priodefs.KeyedDivider[Key],
 	quantity uint,
-	priorities []uint,
-	distribution map[uint]uint,
+	priorities []Key,
+	distribution map[Key]uint,
 ) error {
 	if err := divider(quantity, priorities, distribution); err != nil {
 		return err
@@ -18,9 +18,18 @@ func TestDivide(t *testing.T) {
 		return nil
 	}
 
+	capped := func(_ uint, priorities []uint, distribution map[uint]uint) error {
+		for _, priority := range priorities {
+			distribution[priority] = 1
+		}
+
+		return nil
+	}
+
 	require.NoError(t, divide(divider.Rate, 6, []uint{3, 2, 1}, make(map[uint]uint)))
 	require.NoError(t, divide(divider.Rate, 0, []uint{3, 2, 1}, make(map[uint]uint)))
 	require.Error(t, divide(divider.Rate, 6, []uint{math.MaxUint, 2, 1}, make(map[uint]uint)))
 	require.Error(t, divide(wrong, math.MaxUint, []uint{3, 2, 1}, make(map[uint]uint)))
 	require.Error(t, divide(wrong, 6, []uint{3, 2, 1}, make(map[uint]uint)))
+	require.Error(t, divide(capped, 6, []uint{3, 2, 1}, make(map[uint]uint)))
 }
@@ -0,0 +1,186 @@
+package divider
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/priority/priodefs"
+)
+
+// Creates a divider that prevents starvation of priorities when dividing data items
+// by the specified divider.
+//
+// Priority is considered to be waiting while it is passed to the divider but
+// receives no data handlers. Within the discipline with the PendingOnly option, the
+// divider is passed only the priorities whose input channels have pending data
+// items, so a priority is waiting while its pending data items are not served. For
+// each full patience period of waiting, one data handler is reserved for the
+// priority before the remaining data handlers are divided by the specified
+// divider, so the longer the priority waits the greater its share. Reserved data
+// handlers are distributed in rounds, one per round to each priority that has been
+// waiting long enough, starting with the priority that has been waiting the
+// longest. Priority stops waiting as soon as it receives at least one data handler
+// or is not passed to the divider.
+//
+// Used together with divider.Rate at a small quantity of data handlers and the
+// PendingOnly option of the discipline to guarantee eventual progress for every
+// priority. Without the PendingOnly option the discipline passes to the divider
+// priorities regardless of their pending data items, so the waiting of priorities
+// does not correspond to the waiting of their data items. While every priority
+// receives data handlers, for example under a sustained load with enough data
+// handlers, the created divider returns the same distribution as the specified
+// divider.
+//
+// For the fixed waiting state and time the created divider preserves the quantity
+// of data handlers and the monotonicity of the specified divider.
+//
+// Created divider keeps the waiting state of priorities, so it should not be shared
+// between disciplines. A zero or negative patience is treated as the smallest
+// possible patience. Clock is used to measure the waiting durations, if it is not
+// specified, then the real clock is used.
+func Aging[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	patience time.Duration,
+	clk clock.Clock,
+) priodefs.KeyedDivider[Key] {
+	return newAging(divider, patience, clk).divide
+}
+
+type aging[Key comparable] struct {
+	clock    clock.Clock
+	divider  priodefs.KeyedDivider[Key]
+	patience time.Duration
+
+	mutex    sync.Mutex
+	divided  map[Key]uint
+	eligible []Key
+	passed   map[Key]struct{}
+	reserved []Key
+	waiting  map[Key]time.Time
+}
+
+func newAging[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	patience time.Duration,
+	clk clock.Clock,
+) *aging[Key] {
+	if patience <= 0 {
+		patience = time.Nanosecond
+	}
+
+	ag := &aging[Key]{
+		clock:    clock.Ensure(clk),
+		divider:  divider,
+		patience: patience,
+
+		divided: make(map[Key]uint),
+		passed:  make(map[Key]struct{}),
+		waiting: make(map[Key]time.Time),
+	}
+
+	return ag
+}
+
+func (ag *aging[Key]) divide(quantity uint, priorities []Key, distribution map[Key]uint) error {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+
+	now := ag.clock.Now()
+
+	ag.forget(priorities)
+	ag.reserve(quantity, priorities, now)
+
+	clear(ag.divided)
+
+	// Quantity of reserved data handlers never exceeds the quantity of data handlers
+	if err := ag.divider(quantity-uint(len(ag.reserved)), priorities, ag.divided); err != nil {
+		return err
+	}
+
+	for _, priority := range ag.reserved {
+		ag.divided[priority]++
+	}
+
+	for _, priority := range priorities {
+		distribution[priority] += ag.divided[priority]
+	}
+
+	ag.track(priorities, now)
+
+	return nil
+}
+
+// Removes the waiting state of priorities that are not passed to the divider.
+func (ag *aging[Key]) forget(priorities []Key) {
+	clear(ag.passed)
+
+	for _, priority := range priorities {
+		ag.passed[priority] = struct{}{}
+	}
+
+	for priority := range ag.waiting {
+		if _, passed := ag.passed[priority]; !passed {
+			delete(ag.waiting, priority)
+		}
+	}
+}
+
+// Updates the waiting state of priorities according to the created distribution.
+func (ag *aging[Key]) track(priorities []Key, now time.Time) {
+	for _, priority := range priorities {
+		if ag.divided[priority] != 0 {
+			delete(ag.waiting, priority)
+			continue
+		}
+
+		if _, exists := ag.waiting[priority]; !exists {
+			ag.waiting[priority] = now
+		}
+	}
+}
+
+// Fills the list of data handlers reserved for waiting priorities.
+func (ag *aging[Key]) reserve(quantity uint, priorities []Key, now time.Time) {
+	ag.reserved = ag.reserved[:0]
+
+	for round := time.Duration(1); ; round++ {
+		ag.eligible = ag.eligible[:0]
+
+		for _, priority := range priorities {
+			since, exists := ag.waiting[priority]
+			if !exists {
+				continue
+			}
+
+			// Integer overflow is practically impossible because the round is
+			// increased only while the product does not exceed the waiting duration
+			// of some priority, so it exceeds it by no more than one patience period
+			if now.Sub(since) >= round*ag.patience {
+				ag.eligible = append(ag.eligible, priority)
+			}
+		}
+
+		if len(ag.eligible) == 0 {
+			return
+		}
+
+		// Stable sorting keeps the order of the passed priorities for priorities
+		// that have been waiting for the same duration
+		slices.SortStableFunc(ag.eligible, func(first, second Key) int {
+			return ag.waiting[first].Compare(ag.waiting[second])
+		})
+
+		for _, priority := range ag.eligible {
+			// Integer overflow is impossible because len() function returns only
+			// positive values for type int and the maximum value for type int is
+			// less than the maximum value for type uint
+			if uint(len(ag.reserved)) == quantity {
+				return
+			}
+
+			ag.reserved = append(ag.reserved, priority)
+		}
+	}
+}
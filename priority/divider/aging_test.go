@@ -0,0 +1,122 @@
+package divider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/clock"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAging(t *testing.T) {
+	const patience = time.Second
+
+	clk := clock.NewManual(time.Now())
+
+	aging := newAging(Rate, patience, clk)
+
+	// Without waiting it works as the wrapped divider
+	testDivider(t, aging.divide, 6, []uint{3, 2, 1}, map[uint]uint{3: 3, 2: 2, 1: 1})
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+
+	// Priorities are waiting, but not long enough
+	clk.Advance(patience / 2)
+
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+
+	// Priority that receives a data handler stops waiting, so the others take turns
+	clk.Advance(patience / 2)
+
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 0})
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 0, 1: 1})
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+
+	clk.Advance(patience)
+
+	testDivider(t, aging.divide, 2, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 1})
+	testDivider(t, aging.divide, 4, []uint{3, 2, 1}, map[uint]uint{3: 2, 2: 1, 1: 1})
+
+	// Priority that is not passed to the divider stops waiting
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+	testDivider(t, aging.divide, 1, []uint{3, 2}, map[uint]uint{3: 1, 2: 0})
+
+	clk.Advance(patience)
+
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 0})
+	testDivider(t, aging.divide, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+}
+
+func TestAgingShare(t *testing.T) {
+	const patience = time.Second
+
+	clk := clock.NewManual(time.Now())
+
+	aging := newAging(Rate, patience, clk)
+
+	testDivider(t, aging.divide, 1, []uint{7}, map[uint]uint{7: 1})
+
+	clk.Advance(2 * patience)
+
+	// Priority 1 starts waiting
+	testDivider(t, aging.divide, 1, []uint{7, 1}, map[uint]uint{7: 1, 1: 0})
+
+	clk.Advance(3 * patience)
+
+	// The longer the priority waits the greater its share
+	testDivider(t, aging.divide, 8, []uint{7, 1}, map[uint]uint{7: 4, 1: 4})
+	testDivider(t, aging.divide, 8, []uint{7, 1}, map[uint]uint{7: 7, 1: 1})
+	testDivider(t, aging.divide, 1, []uint{7, 1}, map[uint]uint{7: 1, 1: 0})
+
+	clk.Advance(3 * patience)
+
+	// Reservation does not exceed the quantity of data handlers
+	testDivider(t, aging.divide, 0, []uint{7, 1}, map[uint]uint{7: 0, 1: 0})
+	testDivider(t, aging.divide, 1, []uint{7, 1}, map[uint]uint{7: 0, 1: 1})
+}
+
+func TestAgingSustained(t *testing.T) {
+	const (
+		handlersQuantity = 12
+		patience         = time.Second
+	)
+
+	clk := clock.NewManual(time.Now())
+
+	aging := newAging(Rate, patience, clk)
+
+	// Priorities that are served in every call do not wait, so the distribution
+	// does not drift from the one of the wrapped divider
+	for range 10 {
+		testDivider(t, aging.divide, handlersQuantity, []uint{3, 1}, map[uint]uint{3: 9, 1: 3})
+
+		clk.Advance(patience)
+	}
+}
+
+func TestAgingError(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	failed := func(uint, []uint, map[uint]uint) error {
+		return errFailed
+	}
+
+	require.ErrorIs(t, Aging(failed, 0, nil)(1, []uint{3, 2, 1}, map[uint]uint{}), errFailed)
+}
+
+func BenchmarkAging(b *testing.B) {
+	priorities := []uint{3, 2, 1}
+	distribution := make(map[uint]uint)
+
+	aging := Aging(Rate, time.Hour, nil)
+
+	var err error
+
+	for range b.N {
+		err = aging(11, priorities, distribution)
+	}
+
+	require.NoError(b, err)
+	require.NotNil(b, distribution)
+}
@@ -0,0 +1,236 @@
+package divider
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/akramarenkov/flow/priority/priodefs"
+
+	"github.com/akramarenkov/safe"
+)
+
+var (
+	ErrBoundsCrossed      = errors.New("minimum quantity of priority exceeds its maximum quantity")
+	ErrBoundsInsufficient = errors.New("quantity of data handlers exceeds the sum of maximum quantities")
+	ErrBoundsOversized    = errors.New("sum of minimum quantities exceeds quantity of data handlers")
+)
+
+// Creates a divider that applies per-priority minimum and maximum quantities of data
+// handlers on top of the specified divider.
+//
+// Map key is a value of priority and map value is the minimum (floors) or the
+// maximum (ceilings) quantity of data handlers for this priority. Priorities that are
+// absent in the maps are not bounded.
+//
+// Quantity is the quantity of data handlers for which the bounds are designed,
+// usually equal to the HandlersQuantity option of the discipline. Creation fails if
+// the sum of the minimum quantities exceeds it or if the minimum quantity of any
+// priority exceeds its maximum quantity. Creation also fails if the sum of the
+// maximum quantities is less than it and there is no priority known to be unbounded
+// from above, that is, specified in the floors and absent in the ceilings. To leave
+// a priority unbounded from above without the minimum quantity, specify it in the
+// floors with a zero value.
+//
+// Data handlers are distributed as follows. At first, the minimum quantities of the
+// specified priorities are filled in rounds, one data handler per round to each
+// priority whose minimum quantity has not yet been reached, starting with the
+// highest priority. The remaining data handlers are divided by the specified
+// divider, after which the quantities that exceed the maximum are cut and the
+// surplus is given to the priorities that have not reached the maximum, starting
+// with the highest priority. Thus, when the divider is called with a quantity of data
+// handlers smaller than the sum of the minimum quantities, for example when dividing
+// vacant data handlers, the minimum quantities are met only partially.
+//
+// If all the priorities passed to the created divider are bounded from above and the
+// quantity of data handlers passed to it exceeds the sum of their maximum quantities,
+// then the surplus is divided between them by the specified divider in excess of
+// the maximum quantities. For example, this happens when only the bounded priorities
+// have data items, so that the discipline uses all data handlers for them. If the
+// specified divider preserves the quantity of data handlers and is monotonic, then
+// the created divider is too.
+func Bounded[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	quantity uint,
+	floors map[Key]uint,
+	ceilings map[Key]uint,
+) (priodefs.KeyedDivider[Key], error) {
+	total := uint(0)
+	unbounded := len(ceilings) == 0
+
+	for priority, floor := range floors {
+		ceiling, exists := ceilings[priority]
+		if !exists {
+			unbounded = true
+		}
+
+		if exists && floor > ceiling {
+			return nil, fmt.Errorf("%w: %v", ErrBoundsCrossed, priority)
+		}
+
+		sum, err := safe.Add(total, floor)
+		if err != nil {
+			return nil, fmt.Errorf("calculation of the sum of minimum quantities: %w", err)
+		}
+
+		total = sum
+	}
+
+	if total > quantity {
+		return nil, ErrBoundsOversized
+	}
+
+	if !unbounded && !isCeilingsSufficient(quantity, ceilings) {
+		return nil, ErrBoundsInsufficient
+	}
+
+	// Maps are cloned to protect against their modification after the divider is
+	// created
+	bnd := &bounded[Key]{
+		ceilings: maps.Clone(ceilings),
+		divider:  divider,
+		floors:   maps.Clone(floors),
+
+		divided:  make(map[Key]uint),
+		exceeded: make(map[Key]uint),
+		floored:  make(map[Key]uint),
+	}
+
+	return bnd.divide, nil
+}
+
+type bounded[Key comparable] struct {
+	ceilings map[Key]uint
+	divider  priodefs.KeyedDivider[Key]
+	floors   map[Key]uint
+
+	mutex    sync.Mutex
+	divided  map[Key]uint
+	exceeded map[Key]uint
+	floored  map[Key]uint
+}
+
+// Checks whether the sum of the maximum quantities is not less than the quantity of
+// data handlers.
+func isCeilingsSufficient[Key comparable](quantity uint, ceilings map[Key]uint) bool {
+	total := uint(0)
+
+	for _, ceiling := range ceilings {
+		// Integer overflow is impossible because the total is less than the quantity
+		// of data handlers, otherwise the loop is terminated
+		if total >= quantity || ceiling >= quantity-total {
+			return true
+		}
+
+		total += ceiling
+	}
+
+	return total >= quantity
+}
+
+func (bnd *bounded[Key]) divide(quantity uint, priorities []Key, distribution map[Key]uint) error {
+	bnd.mutex.Lock()
+	defer bnd.mutex.Unlock()
+
+	clear(bnd.divided)
+	clear(bnd.exceeded)
+	clear(bnd.floored)
+
+	remainder := bnd.fillFloors(quantity, priorities)
+
+	if err := bnd.divider(remainder, priorities, bnd.divided); err != nil {
+		return err
+	}
+
+	surplus := uint(0)
+
+	for _, priority := range priorities {
+		room, bounded := bnd.room(priority)
+		if !bounded {
+			continue
+		}
+
+		if bnd.divided[priority] > room {
+			// Integer overflow is impossible because the surplus never exceeds the
+			// quantity of data handlers
+			surplus += bnd.divided[priority] - room
+			bnd.divided[priority] = room
+		}
+	}
+
+	// Surplus is given in a fixed order of priorities to keep the monotonicity
+	for _, priority := range priorities {
+		if surplus == 0 {
+			break
+		}
+
+		part := surplus
+
+		if room, bounded := bnd.room(priority); bounded {
+			// Share never exceeds the room, it is cut above
+			part = min(room-bnd.divided[priority], surplus)
+		}
+
+		bnd.divided[priority] += part
+		surplus -= part
+	}
+
+	// Surplus remaining here means that all the priorities have reached their
+	// maximum quantities, so it can only be given in excess of them
+	if surplus != 0 {
+		if err := bnd.divider(surplus, priorities, bnd.exceeded); err != nil {
+			return err
+		}
+	}
+
+	for _, priority := range priorities {
+		distribution[priority] += bnd.floored[priority] + bnd.divided[priority] +
+			bnd.exceeded[priority]
+	}
+
+	return nil
+}
+
+// Returns the quantity of data handlers that can be given to the priority in
+// addition to its filled minimum quantity. Returns false if the priority is not
+// bounded from above.
+func (bnd *bounded[Key]) room(priority Key) (uint, bool) {
+	ceiling, exists := bnd.ceilings[priority]
+	if !exists {
+		return 0, false
+	}
+
+	// Minimum quantity never exceeds the maximum quantity, it is checked when the
+	// divider is created
+	return ceiling - bnd.floored[priority], true
+}
+
+// Fills the minimum quantities of the specified priorities in rounds and returns the
+// quantity of data handlers remaining after that.
+func (bnd *bounded[Key]) fillFloors(quantity uint, priorities []Key) uint {
+	for round := uint(1); quantity != 0; round++ {
+		added := false
+
+		for _, priority := range priorities {
+			if bnd.floors[priority] < round {
+				continue
+			}
+
+			if quantity == 0 {
+				return 0
+			}
+
+			bnd.floored[priority]++
+			quantity--
+
+			added = true
+		}
+
+		if !added {
+			return quantity
+		}
+	}
+
+	return quantity
+}
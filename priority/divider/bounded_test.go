@@ -0,0 +1,123 @@
+package divider
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounded(t *testing.T) {
+	bounded, err := Bounded(Rate, 10, map[uint]uint{1: 3}, map[uint]uint{3: 4})
+	require.NoError(t, err)
+
+	testDivider(t, bounded, 10, []uint{3, 2, 1}, map[uint]uint{3: 4, 2: 2, 1: 4})
+	testDivider(t, bounded, 12, []uint{3, 2, 1}, map[uint]uint{3: 4, 2: 3, 1: 5})
+
+	// Minimum quantity is met partially
+	testDivider(t, bounded, 0, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 0, 1: 0})
+	testDivider(t, bounded, 2, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 0, 1: 2})
+	testDivider(t, bounded, 3, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 0, 1: 3})
+	testDivider(t, bounded, 4, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 3})
+
+	// Surplus is given to the priorities that have not reached the maximum
+	testDivider(t, bounded, 8, []uint{3, 1}, map[uint]uint{3: 4, 1: 4})
+
+	// Surplus is given in excess of the maximum if all priorities have reached it
+	testDivider(t, bounded, 8, []uint{3}, map[uint]uint{3: 8})
+
+	testDivider(t, bounded, 4, []uint{3}, map[uint]uint{3: 4})
+	testDivider(t, bounded, 4, []uint{2}, map[uint]uint{2: 4})
+}
+
+func TestBoundedExcess(t *testing.T) {
+	bounded, err := Bounded(Rate, 6, nil, map[uint]uint{2: 3, 1: 3})
+	require.NoError(t, err)
+
+	testDivider(t, bounded, 6, []uint{2, 1}, map[uint]uint{2: 3, 1: 3})
+	testDivider(t, bounded, 9, []uint{2, 1}, map[uint]uint{2: 5, 1: 4})
+	testDivider(t, bounded, 6, []uint{2}, map[uint]uint{2: 6})
+	testDivider(t, bounded, 6, []uint{1}, map[uint]uint{1: 6})
+}
+
+func TestBoundedSeveralFloors(t *testing.T) {
+	bounded, err := Bounded(Fair, 6, map[uint]uint{2: 1, 1: 3}, map[uint]uint{1: 3})
+	require.NoError(t, err)
+
+	testDivider(t, bounded, 1, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 0})
+	testDivider(t, bounded, 2, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 1})
+	testDivider(t, bounded, 3, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 2})
+	testDivider(t, bounded, 4, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 1, 1: 3})
+	testDivider(t, bounded, 6, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 2, 1: 3})
+	testDivider(t, bounded, 9, []uint{3, 2, 1}, map[uint]uint{3: 3, 2: 3, 1: 3})
+}
+
+func TestBoundedInvariants(t *testing.T) {
+	const quantity = 100
+
+	bounded, err := Bounded(
+		Rate,
+		quantity,
+		map[uint]uint{2: 5, 1: 10},
+		map[uint]uint{3: 20, 1: 15},
+	)
+	require.NoError(t, err)
+
+	priorities := []uint{3, 2, 1}
+	previous := make(map[uint]uint)
+
+	for quantity := range uint(2 * quantity) {
+		distribution := make(map[uint]uint)
+
+		require.NoError(t, bounded(quantity, priorities, distribution))
+		require.Equal(t, quantity, distribution[3]+distribution[2]+distribution[1])
+		require.LessOrEqual(t, distribution[3], uint(20))
+		require.LessOrEqual(t, distribution[1], uint(15))
+
+		if quantity >= 15 {
+			require.GreaterOrEqual(t, distribution[2], uint(5))
+			require.GreaterOrEqual(t, distribution[1], uint(10))
+		}
+
+		for _, priority := range priorities {
+			require.GreaterOrEqual(t, distribution[priority], previous[priority])
+		}
+
+		previous = distribution
+	}
+}
+
+func TestBoundedError(t *testing.T) {
+	_, err := Bounded(Rate, 10, map[uint]uint{1: 3}, map[uint]uint{1: 2})
+	require.ErrorIs(t, err, ErrBoundsCrossed)
+
+	_, err = Bounded(Rate, 5, map[uint]uint{2: 3, 1: 3}, nil)
+	require.ErrorIs(t, err, ErrBoundsOversized)
+
+	_, err = Bounded(Rate, math.MaxUint, map[uint]uint{2: math.MaxUint, 1: 1}, nil)
+	require.Error(t, err)
+
+	_, err = Bounded(Rate, 10, nil, map[uint]uint{2: 3, 1: 3})
+	require.ErrorIs(t, err, ErrBoundsInsufficient)
+
+	_, err = Bounded(Rate, 10, map[uint]uint{2: 3}, map[uint]uint{2: 3, 1: 3})
+	require.ErrorIs(t, err, ErrBoundsInsufficient)
+
+	_, err = Bounded(Rate, 10, map[uint]uint{3: 0}, map[uint]uint{2: 3, 1: 3})
+	require.NoError(t, err)
+
+	_, err = Bounded(Rate, math.MaxUint, nil, map[uint]uint{2: math.MaxUint, 1: 1})
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+
+	failed := func(uint, []uint, map[uint]uint) error {
+		return errFailed
+	}
+
+	bounded, err := Bounded(failed, 10, nil, nil)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, bounded(7, []uint{2, 1}, map[uint]uint{}), errFailed)
+}
@@ -3,11 +3,21 @@
 package divider
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+
+	"github.com/akramarenkov/flow/priority/priodefs"
 
 	"github.com/akramarenkov/safe"
 )
 
+var (
+	ErrWeightNotFound = errors.New("weight of priority was not found")
+	ErrWeightZero     = errors.New("weight of priority is zero")
+	ErrWeightsEmpty   = errors.New("weights of priorities were not specified")
+)
+
 // Distributes data items evenly among data handlers.
 //
 // Used for equaling.
@@ -21,6 +31,12 @@ import (
 //   - 10 / [7 2 1] = map[7:4 2:3 1:3]
 //   - 100 / [70 20 10] = map[70:34 20:33 10:33]
 func Fair(quantity uint, priorities []uint, distribution map[uint]uint) error {
+	return KeyedFair(quantity, priorities, distribution)
+}
+
+// Distributes data items evenly among data handlers like divider.Fair, but for
+// priorities of any type.
+func KeyedFair[Key comparable](quantity uint, priorities []Key, distribution map[Key]uint) error {
 	divider := uint(len(priorities))
 	base := quantity / divider
 	remainder := quantity % divider
@@ -59,6 +75,125 @@ func Rate(quantity uint, priorities []uint, distribution map[uint]uint) error {
 		return fmt.Errorf("calculation of the sum of priorities: %w", err)
 	}
 
+	rate(quantity, divider, priorities, distribution, func(priority uint) uint {
+		return priority
+	})
+
+	return nil
+}
+
+// Creates a divider that distributes data items among data handlers in ratio to the
+// weights of priorities returned by the specified function.
+//
+// Used for prioritization with priorities of any type. Works like divider.Rate, but
+// uses the weight of the priority instead of its value. Divider returns an error if
+// the weight of any priority passed to it is zero or if the sum of weights
+// overflows.
+//
+// To keep processing data items of all priorities, the quantity of data handlers must
+// be no less than the sum of the weights of all priorities.
+func RateBy[Key comparable](weight func(priority Key) uint) priodefs.KeyedDivider[Key] {
+	divide := func(quantity uint, priorities []Key, distribution map[Key]uint) error {
+		divider := uint(0)
+
+		for _, priority := range priorities {
+			if weight(priority) == 0 {
+				return fmt.Errorf("%w: %v", ErrWeightZero, priority)
+			}
+
+			sum, err := safe.Add(divider, weight(priority))
+			if err != nil {
+				return fmt.Errorf("calculation of the sum of weights: %w", err)
+			}
+
+			divider = sum
+		}
+
+		rate(quantity, divider, priorities, distribution, weight)
+
+		return nil
+	}
+
+	return divide
+}
+
+// Creates a divider that distributes data items among data handlers in ratio to the
+// weights of priorities.
+//
+// Used for prioritization when the order of priorities and their shares should be
+// specified separately. Works like divider.Rate, including the rapid achievement of
+// full distribution filling at small quantities of data handlers, but uses the
+// weight of the priority instead of its value.
+//
+// Map key is a value of priority and map value is its weight. Weights cannot be zero
+// and their sum must not overflow. Divider returns an error if a priority without
+// weight is passed to it.
+//
+// To keep processing data items of all priorities, the quantity of data handlers must
+// be no less than the sum of the weights of all priorities.
+//
+// Example results for weights map[3:70 2:20 1:10]:
+//
+//   - 10 / [3 2 1] = map[3:8 2:1 1:1]
+//   - 100 / [3 2 1] = map[3:70 2:20 1:10]
+//   - 100 / [3 1] = map[3:89 1:11]
+func Weighted[Key comparable](weights map[Key]uint) (priodefs.KeyedDivider[Key], error) {
+	if len(weights) == 0 {
+		return nil, ErrWeightsEmpty
+	}
+
+	total := uint(0)
+
+	for _, weight := range weights {
+		if weight == 0 {
+			return nil, ErrWeightZero
+		}
+
+		sum, err := safe.Add(total, weight)
+		if err != nil {
+			return nil, fmt.Errorf("calculation of the sum of weights: %w", err)
+		}
+
+		total = sum
+	}
+
+	// Protection against modification of the map after the divider is created
+	weights = maps.Clone(weights)
+
+	weight := func(priority Key) uint {
+		return weights[priority]
+	}
+
+	divide := func(quantity uint, priorities []Key, distribution map[Key]uint) error {
+		divider := uint(0)
+
+		for _, priority := range priorities {
+			if weights[priority] == 0 {
+				return fmt.Errorf("%w: %v", ErrWeightNotFound, priority)
+			}
+
+			// Integer overflow is impossible because the sum of all weights was
+			// checked when creating the divider
+			divider += weights[priority]
+		}
+
+		rate(quantity, divider, priorities, distribution, weight)
+
+		return nil
+	}
+
+	return divide, nil
+}
+
+// Distributes data items among data handlers in ratio to the weights of priorities.
+// Divider is the sum of the weights of the specified priorities.
+func rate[Key comparable](
+	quantity uint,
+	divider uint,
+	priorities []Key,
+	distribution map[Key]uint,
+	weight func(priority Key) uint,
+) {
 	base := quantity / divider
 	remainder := quantity % divider
 
@@ -73,7 +208,7 @@ func Rate(quantity uint, priorities []uint, distribution map[uint]uint) error {
 	remainder -= quicking
 
 	for _, priority := range priorities {
-		part := base * priority
+		part := base * weight(priority)
 
 		if quicking != 0 {
 			part++
@@ -81,7 +216,7 @@ func Rate(quantity uint, priorities []uint, distribution map[uint]uint) error {
 		}
 
 		// Minus one due to quicking
-		rating := priority - 1
+		rating := weight(priority) - 1
 
 		if remainder < rating {
 			part += remainder
@@ -93,6 +228,40 @@ func Rate(quantity uint, priorities []uint, distribution map[uint]uint) error {
 
 		distribution[priority] += part
 	}
+}
+
+// Distributes all data items to the highest priority.
+//
+// Used for strict prioritization: data items of a lower priority are processed only
+// when there are no data items of higher priorities. Requires the PendingOnly option
+// of the discipline to be set, since then only priorities with pending data items are
+// passed to the divider and lower priorities receive vacant data handlers left after
+// the input channels of higher priorities have run out of data items.
+//
+// The divider preserves the quantity of data handlers and is monotonic, because the
+// quantity for the highest priority is equal to the quantity of data handlers and
+// the quantity for the rest of the priorities is always zero. Thus, it passes the
+// inspect.IsQuantityPreserved and inspect.IsMonotonic checks, but does not pass the
+// inspect.IsNonFatalQuantity and inspect.IsSuitableQuantity checks for more than
+// one priority. Any quantity of data handlers greater than zero is non-fatal for
+// a single priority, while for several priorities there is no non-fatal quantity,
+// so any quantity of data handlers greater than zero can be used with the PendingOnly
+// option.
+//
+// Example results:
+//
+//   - 6 / [3 2 1] = map[3:6]
+//   - 10 / [7 2 1] = map[7:10]
+//   - 100 / [70 20 10] = map[70:100]
+func Strict(quantity uint, priorities []uint, distribution map[uint]uint) error {
+	return KeyedStrict(quantity, priorities, distribution)
+}
+
+// Distributes all data items to the highest priority like divider.Strict, but for
+// priorities of any type.
+func KeyedStrict[Key comparable](quantity uint, priorities []Key, distribution map[Key]uint) error {
+	// Priority list is sorted in descending order and cannot be of zero length
+	distribution[priorities[0]] += quantity
 
 	return nil
 }
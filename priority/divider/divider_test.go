@@ -91,18 +91,133 @@ func TestRate(t *testing.T) {
 	testDivider(t, Rate, 20, []uint{4, 3, 2, 1}, map[uint]uint{4: 8, 3: 6, 2: 4, 1: 2})
 }
 
+func TestWeighted(t *testing.T) {
+	weighted, err := Weighted(map[uint]uint{3: 70, 2: 20, 1: 10})
+	require.NoError(t, err)
+
+	testDivider(t, weighted, 10, []uint{3, 2, 1}, map[uint]uint{3: 8, 2: 1, 1: 1})
+	testDivider(t, weighted, 100, []uint{3, 2, 1}, map[uint]uint{3: 70, 2: 20, 1: 10})
+	testDivider(t, weighted, 100, []uint{3, 1}, map[uint]uint{3: 89, 1: 11})
+
+	// Quicking
+	testDivider(t, weighted, 0, []uint{3, 2, 1}, map[uint]uint{3: 0, 2: 0, 1: 0})
+	testDivider(t, weighted, 1, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 0, 1: 0})
+	testDivider(t, weighted, 2, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 1, 1: 0})
+	testDivider(t, weighted, 3, []uint{3, 2, 1}, map[uint]uint{3: 1, 2: 1, 1: 1})
+	testDivider(t, weighted, 4, []uint{3, 2, 1}, map[uint]uint{3: 2, 2: 1, 1: 1})
+
+	// Same as Rate when weights are equal to priorities
+	rated, err := Weighted(map[uint]uint{4: 4, 3: 3, 2: 2, 1: 1})
+	require.NoError(t, err)
+
+	for quantity := range uint(21) {
+		expected := make(map[uint]uint)
+
+		require.NoError(t, Rate(quantity, []uint{4, 3, 2, 1}, expected))
+		testDivider(t, rated, quantity, []uint{4, 3, 2, 1}, expected)
+	}
+}
+
+func TestWeightedError(t *testing.T) {
+	_, err := Weighted[uint](nil)
+	require.ErrorIs(t, err, ErrWeightsEmpty)
+
+	_, err = Weighted(map[uint]uint{2: 1, 1: 0})
+	require.ErrorIs(t, err, ErrWeightZero)
+
+	_, err = Weighted(map[uint]uint{2: math.MaxUint, 1: 1})
+	require.Error(t, err)
+
+	weights := map[uint]uint{2: 2, 1: 1}
+
+	weighted, err := Weighted(weights)
+	require.NoError(t, err)
+
+	// Modification of the map does not affect the created divider
+	delete(weights, 2)
+
+	testDivider(t, weighted, 3, []uint{2, 1}, map[uint]uint{2: 2, 1: 1})
+
+	require.ErrorIs(t, weighted(3, []uint{3, 2, 1}, map[uint]uint{}), ErrWeightNotFound)
+}
+
+func TestStrict(t *testing.T) {
+	testDivider(t, Strict, 6, []uint{3, 2, 1}, map[uint]uint{3: 6})
+	testDivider(t, Strict, 10, []uint{7, 2, 1}, map[uint]uint{7: 10})
+	testDivider(t, Strict, 100, []uint{70, 20, 10}, map[uint]uint{70: 100})
+
+	testDivider(t, Strict, 0, []uint{3}, map[uint]uint{3: 0})
+	testDivider(t, Strict, 1, []uint{3}, map[uint]uint{3: 1})
+	testDivider(t, Strict, 0, []uint{3, 2, 1}, map[uint]uint{3: 0})
+	testDivider(t, Strict, 1, []uint{3, 2, 1}, map[uint]uint{3: 1})
+	testDivider(t, Strict, 2, []uint{2, 1}, map[uint]uint{2: 2})
+}
+
+func TestRateBy(t *testing.T) {
+	weights := map[string]uint{"gold": 3, "silver": 2, "bronze": 1}
+
+	weight := func(priority string) uint {
+		return weights[priority]
+	}
+
+	rated := RateBy(weight)
+	priorities := []string{"gold", "silver", "bronze"}
+
+	testDivider(t, rated, 6, priorities, map[string]uint{"gold": 3, "silver": 2, "bronze": 1})
+	testDivider(t, rated, 4, priorities, map[string]uint{"gold": 2, "silver": 1, "bronze": 1})
+	testDivider(t, rated, 4, []string{"gold", "bronze"}, map[string]uint{"gold": 3, "bronze": 1})
+
+	// Same as Rate when weights are equal to priorities
+	for quantity := range uint(21) {
+		expected := make(map[uint]uint)
+
+		require.NoError(t, Rate(quantity, []uint{4, 3, 2, 1}, expected))
+		identity := func(priority uint) uint {
+			return priority
+		}
+
+		testDivider(t, RateBy(identity), quantity, []uint{4, 3, 2, 1}, expected)
+	}
+}
+
+func TestRateByError(t *testing.T) {
+	weights := map[string]uint{"gold": math.MaxUint, "silver": 1}
+
+	weight := func(priority string) uint {
+		return weights[priority]
+	}
+
+	rated := RateBy(weight)
+
+	require.ErrorIs(t, rated(1, []string{"bronze"}, map[string]uint{}), ErrWeightZero)
+	require.Error(t, rated(1, []string{"gold", "silver"}, map[string]uint{}))
+}
+
+func TestKeyed(t *testing.T) {
+	priorities := []string{"gold", "silver", "bronze"}
+
+	testDivider(t, KeyedFair, 5, priorities, map[string]uint{"gold": 2, "silver": 2, "bronze": 1})
+	testDivider(t, KeyedStrict, 5, priorities, map[string]uint{"gold": 5})
+
+	weighted, err := Weighted(map[string]uint{"gold": 70, "silver": 20, "bronze": 10})
+	require.NoError(t, err)
+
+	testDivider(t, weighted, 100, priorities, map[string]uint{"gold": 70, "silver": 20, "bronze": 10})
+	require.ErrorIs(t, weighted(1, []string{"iron"}, map[string]uint{}), ErrWeightNotFound)
+}
+
 func TestRateError(t *testing.T) {
 	require.Error(t, Rate(1, []uint{math.MaxUint, 1}, map[uint]uint{}))
 }
 
-func testDivider(
+func testDivider[Key comparable](
 	t *testing.T,
-	divider priodefs.Divider,
+	divider priodefs.KeyedDivider[Key],
 	quantity uint,
-	priorities []uint,
-	expected map[uint]uint,
+	priorities []Key,
+	expected map[Key]uint,
 ) {
-	distribution := make(map[uint]uint)
+	distribution := make(map[Key]uint)
 
 	require.NoError(t, divider(quantity, priorities, distribution))
 	require.Equal(t, expected, distribution)
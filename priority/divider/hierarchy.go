@@ -0,0 +1,235 @@
+package divider
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/akramarenkov/flow/priority/priodefs"
+)
+
+var (
+	ErrGroupDividerEmpty  = errors.New("divider of group was not specified")
+	ErrGroupEmpty         = errors.New("group has neither priorities nor subgroups")
+	ErrGroupKeyDuplicated = errors.New("priority within group is used by both a subgroup and an input")
+	ErrGroupPriorityZero  = errors.New("zero priority is specified in group")
+	ErrPriorityDuplicated = errors.New("priority is specified in several groups")
+	ErrPriorityUnknown    = errors.New("priority is absent in groups")
+)
+
+// Group of priorities of input channels and of subgroups whose data handlers are
+// divided by its own divider.
+//
+// Priorities within the groups are always of uint type, while priorities of input
+// channels can be of any type.
+type KeyedGroup[Key comparable] struct {
+	// Divides data handlers given to the group among its priorities and subgroups
+	Divider priodefs.Divider
+
+	// Subgroups of the group. Map key is a priority of the subgroup within the group
+	// which is passed to the divider of the group
+	Groups map[uint]KeyedGroup[Key]
+
+	// Priorities of input channels belonging to the group. Map key is a priority
+	// within the group which is passed to the divider of the group, map value is
+	// the priority of the input channel in the discipline
+	//
+	// Thus, different groups can use the same priorities within the group, for
+	// example, to divide data handlers among their input channels by divider.Rate in
+	// the same ratio
+	Priorities map[uint]Key
+}
+
+// Group of priorities of input channels of uint type.
+type Group = KeyedGroup[uint]
+
+// Creates a divider that divides data handlers according to the hierarchy of groups.
+//
+// Data handlers are divided by the divider of the root group among its priorities
+// and subgroups, then data handlers given to each subgroup are divided by its divider
+// and so on. Priorities and subgroups that have no priorities passed to the created
+// divider are not passed to the dividers of the groups. Thus, both the strategic
+// distribution and the distributions of vacant data handlers among some of the
+// priorities are calculated by applying the dividers of the groups recursively.
+//
+// For example, to divide data handlers fairly among tenants and by divider.Rate among
+// the priority classes inside each tenant:
+//
+//	Group{
+//		Divider: divider.Fair,
+//		Groups: map[uint]Group{
+//			1: {Divider: divider.Rate, Priorities: map[uint]uint{3: 3, 2: 2, 1: 1}},
+//			2: {Divider: divider.Rate, Priorities: map[uint]uint{3: 6, 2: 5, 1: 4}},
+//		},
+//	}
+//
+// If the dividers of all groups preserve the quantity of data handlers and are
+// monotonic, then the created divider is too. To keep processing data items of all
+// priorities, the quantity of data handlers must be non-fatal for each group at the
+// quantity of data handlers given to it.
+func Hierarchy(root Group) (priodefs.Divider, error) {
+	return KeyedHierarchy(root)
+}
+
+// Creates a divider that divides data handlers according to the hierarchy of groups
+// for priorities of input channels of any type. See [Hierarchy] for details.
+func KeyedHierarchy[Key comparable](root KeyedGroup[Key]) (priodefs.KeyedDivider[Key], error) {
+	hrc := &hierarchy[Key]{
+		owners: make(map[Key]*node[Key]),
+		passed: make(map[Key]bool),
+	}
+
+	top, err := hrc.build(root)
+	if err != nil {
+		return nil, err
+	}
+
+	hrc.root = top
+
+	return hrc.divide, nil
+}
+
+type hierarchy[Key comparable] struct {
+	// Nodes to which priorities of input channels belong
+	owners map[Key]*node[Key]
+	root   *node[Key]
+
+	mutex  sync.Mutex
+	passed map[Key]bool
+}
+
+// Compiled group.
+type node[Key comparable] struct {
+	divider priodefs.Divider
+	// Subgroups by priorities within the group
+	groups map[uint]*node[Key]
+	// Priorities of input channels by priorities within the group
+	inputs map[uint]Key
+
+	// Priorities within the group passed to its divider, sorted in descending order
+	active       []uint
+	distribution map[uint]uint
+}
+
+func (hrc *hierarchy[Key]) build(group KeyedGroup[Key]) (*node[Key], error) {
+	if group.Divider == nil {
+		return nil, ErrGroupDividerEmpty
+	}
+
+	if len(group.Groups) == 0 && len(group.Priorities) == 0 {
+		return nil, ErrGroupEmpty
+	}
+
+	nd := &node[Key]{
+		divider: group.Divider,
+		groups:  make(map[uint]*node[Key], len(group.Groups)),
+		inputs:  make(map[uint]Key, len(group.Priorities)),
+
+		distribution: make(map[uint]uint),
+	}
+
+	var zero Key
+
+	for key, priority := range group.Priorities {
+		if key == 0 || priority == zero {
+			return nil, ErrGroupPriorityZero
+		}
+
+		if _, exists := hrc.owners[priority]; exists {
+			return nil, fmt.Errorf("%w: %v", ErrPriorityDuplicated, priority)
+		}
+
+		hrc.owners[priority] = nd
+		nd.inputs[key] = priority
+	}
+
+	for key, subgroup := range group.Groups {
+		if key == 0 {
+			return nil, ErrGroupPriorityZero
+		}
+
+		if _, exists := group.Priorities[key]; exists {
+			return nil, fmt.Errorf("%w: %d", ErrGroupKeyDuplicated, key)
+		}
+
+		child, err := hrc.build(subgroup)
+		if err != nil {
+			return nil, err
+		}
+
+		nd.groups[key] = child
+	}
+
+	return nd, nil
+}
+
+func (hrc *hierarchy[Key]) divide(quantity uint, priorities []Key, distribution map[Key]uint) error {
+	hrc.mutex.Lock()
+	defer hrc.mutex.Unlock()
+
+	clear(hrc.passed)
+
+	for _, priority := range priorities {
+		if _, exists := hrc.owners[priority]; !exists {
+			return fmt.Errorf("%w: %v", ErrPriorityUnknown, priority)
+		}
+
+		hrc.passed[priority] = true
+	}
+
+	hrc.activate(hrc.root)
+
+	return hrc.divideNode(hrc.root, quantity, distribution)
+}
+
+// Fills the lists of active priorities within the groups and returns true if the
+// group has active priorities.
+func (hrc *hierarchy[Key]) activate(nd *node[Key]) bool {
+	nd.active = nd.active[:0]
+
+	for key, priority := range nd.inputs {
+		if hrc.passed[priority] {
+			nd.active = append(nd.active, key)
+		}
+	}
+
+	for key, child := range nd.groups {
+		if hrc.activate(child) {
+			nd.active = append(nd.active, key)
+		}
+	}
+
+	// Descending order as required for dividers
+	slices.SortFunc(nd.active, func(first, second uint) int {
+		return cmp.Compare(second, first)
+	})
+
+	return len(nd.active) != 0
+}
+
+func (hrc *hierarchy[Key]) divideNode(
+	nd *node[Key],
+	quantity uint,
+	distribution map[Key]uint,
+) error {
+	clear(nd.distribution)
+
+	if err := nd.divider(quantity, nd.active, nd.distribution); err != nil {
+		return err
+	}
+
+	for _, key := range nd.active {
+		if priority, exists := nd.inputs[key]; exists {
+			distribution[priority] += nd.distribution[key]
+			continue
+		}
+
+		if err := hrc.divideNode(nd.groups[key], nd.distribution[key], distribution); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
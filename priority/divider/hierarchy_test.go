@@ -0,0 +1,170 @@
+package divider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchy(t *testing.T) {
+	hierarchy, err := Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				1: {Divider: Rate, Priorities: map[uint]uint{3: 3, 2: 2, 1: 1}},
+				2: {Divider: Rate, Priorities: map[uint]uint{3: 6, 2: 5, 1: 4}},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	testDivider(
+		t,
+		hierarchy,
+		12,
+		[]uint{6, 5, 4, 3, 2, 1},
+		map[uint]uint{6: 3, 5: 2, 4: 1, 3: 3, 2: 2, 1: 1},
+	)
+
+	testDivider(
+		t,
+		hierarchy,
+		24,
+		[]uint{6, 5, 4, 3, 2, 1},
+		map[uint]uint{6: 6, 5: 4, 4: 2, 3: 6, 2: 4, 1: 2},
+	)
+
+	// Groups without passed priorities are not taken into account
+	testDivider(t, hierarchy, 6, []uint{6, 5, 3}, map[uint]uint{6: 2, 5: 1, 3: 3})
+	testDivider(t, hierarchy, 6, []uint{6, 5, 4}, map[uint]uint{6: 3, 5: 2, 4: 1})
+	testDivider(t, hierarchy, 1, []uint{6, 5, 4, 3, 2, 1}, map[uint]uint{6: 1, 5: 0, 4: 0, 3: 0, 2: 0, 1: 0})
+}
+
+func TestHierarchyNested(t *testing.T) {
+	hierarchy, err := Hierarchy(
+		Group{
+			Divider: Rate,
+			Groups: map[uint]Group{
+				2: {
+					Divider: Fair,
+					Groups: map[uint]Group{
+						1: {Divider: Rate, Priorities: map[uint]uint{2: 5, 1: 4}},
+					},
+					Priorities: map[uint]uint{2: 3},
+				},
+			},
+			Priorities: map[uint]uint{1: 2},
+		},
+	)
+	require.NoError(t, err)
+
+	testDivider(t, hierarchy, 9, []uint{5, 4, 3, 2}, map[uint]uint{5: 2, 4: 1, 3: 3, 2: 3})
+	testDivider(t, hierarchy, 3, []uint{5, 2}, map[uint]uint{5: 2, 2: 1})
+}
+
+func TestKeyedHierarchy(t *testing.T) {
+	hierarchy, err := KeyedHierarchy(
+		KeyedGroup[string]{
+			Divider: Fair,
+			Groups: map[uint]KeyedGroup[string]{
+				1: {Divider: Rate, Priorities: map[uint]string{2: "a-high", 1: "a-low"}},
+				2: {Divider: Rate, Priorities: map[uint]string{2: "b-high", 1: "b-low"}},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	testDivider(
+		t,
+		hierarchy,
+		6,
+		[]string{"b-low", "b-high", "a-low", "a-high"},
+		map[string]uint{"a-high": 2, "a-low": 1, "b-high": 2, "b-low": 1},
+	)
+
+	_, err = KeyedHierarchy(
+		KeyedGroup[string]{
+			Divider:    Rate,
+			Priorities: map[uint]string{1: ""},
+		},
+	)
+	require.ErrorIs(t, err, ErrGroupPriorityZero)
+}
+
+func TestHierarchyError(t *testing.T) {
+	_, err := Hierarchy(Group{Priorities: map[uint]uint{1: 1}})
+	require.ErrorIs(t, err, ErrGroupDividerEmpty)
+
+	_, err = Hierarchy(Group{Divider: Fair})
+	require.ErrorIs(t, err, ErrGroupEmpty)
+
+	_, err = Hierarchy(Group{Divider: Fair, Priorities: map[uint]uint{0: 1}})
+	require.ErrorIs(t, err, ErrGroupPriorityZero)
+
+	_, err = Hierarchy(Group{Divider: Fair, Priorities: map[uint]uint{1: 0}})
+	require.ErrorIs(t, err, ErrGroupPriorityZero)
+
+	_, err = Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				0: {Divider: Fair, Priorities: map[uint]uint{1: 1}},
+			},
+		},
+	)
+	require.ErrorIs(t, err, ErrGroupPriorityZero)
+
+	_, err = Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				1: {Divider: Fair, Priorities: map[uint]uint{1: 1}},
+			},
+			Priorities: map[uint]uint{1: 2},
+		},
+	)
+	require.ErrorIs(t, err, ErrGroupKeyDuplicated)
+
+	_, err = Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				1: {Divider: Fair, Priorities: map[uint]uint{1: 1}},
+			},
+			Priorities: map[uint]uint{2: 1},
+		},
+	)
+	require.ErrorIs(t, err, ErrPriorityDuplicated)
+
+	_, err = Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				1: {Priorities: map[uint]uint{1: 1}},
+			},
+		},
+	)
+	require.ErrorIs(t, err, ErrGroupDividerEmpty)
+
+	errFailed := errors.New("failed")
+
+	failed := func(uint, []uint, map[uint]uint) error {
+		return errFailed
+	}
+
+	hierarchy, err := Hierarchy(
+		Group{
+			Divider: Fair,
+			Groups: map[uint]Group{
+				1: {Divider: failed, Priorities: map[uint]uint{1: 1}},
+			},
+			Priorities: map[uint]uint{2: 2},
+		},
+	)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, hierarchy(2, []uint{3}, map[uint]uint{}), ErrPriorityUnknown)
+	require.ErrorIs(t, hierarchy(2, []uint{2, 1}, map[uint]uint{}), errFailed)
+	require.NoError(t, hierarchy(2, []uint{2}, map[uint]uint{}))
+}
@@ -27,19 +27,25 @@ var (
 
 // Checks that the total quantity of data items in the distribution created by the
 // divider is equal to the quantity of data handlers passed to the divider.
-func IsQuantityPreserved(divider priodefs.Divider, set []Opts) Result {
+func IsQuantityPreserved[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	set []KeyedOpts[Key],
+) KeyedResult[Key] {
 	for _, opts := range set {
 		if result := isQuantityPreserved(divider, opts); result.Conclusion != nil {
 			return result
 		}
 	}
 
-	return Result{}
+	return KeyedResult[Key]{}
 }
 
-func isQuantityPreserved(divider priodefs.Divider, opts Opts) Result {
-	distribution := make(map[uint]uint, len(opts.Priorities))
-	priorities := reusable.New[uint](len(opts.Priorities))
+func isQuantityPreserved[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	opts KeyedOpts[Key],
+) KeyedResult[Key] {
+	distribution := make(map[Key]uint, len(opts.Priorities))
+	priorities := reusable.New[Key](len(opts.Priorities))
 
 	for combination := range combin.Every(opts.Priorities) {
 		for quantity := range safe.Iter(0, opts.Quantity) {
@@ -49,7 +55,7 @@ func isQuantityPreserved(divider priodefs.Divider, opts Opts) Result {
 			copy(priorities.Get(len(combination)), combination)
 
 			if err := divider(quantity, priorities.Get(0), distribution); err != nil {
-				result := Result{
+				result := KeyedResult[Key]{
 					Conclusion: ErrDividerFailed,
 					Err:        err,
 					Quantity:   quantity,
@@ -61,7 +67,7 @@ func isQuantityPreserved(divider priodefs.Divider, opts Opts) Result {
 
 			distributed, err := distrib.Quantity(combination, distribution)
 			if err != nil {
-				result := Result{
+				result := KeyedResult[Key]{
 					Conclusion: ErrQuantityCalculationFailed,
 					Err:        err,
 					Quantity:   quantity,
@@ -72,7 +78,7 @@ func isQuantityPreserved(divider priodefs.Divider, opts Opts) Result {
 			}
 
 			if distributed != quantity {
-				result := Result{
+				result := KeyedResult[Key]{
 					Conclusion: ErrQuantityNotPreserved,
 					Quantity:   quantity,
 					Priorities: combination,
@@ -83,36 +89,42 @@ func isQuantityPreserved(divider priodefs.Divider, opts Opts) Result {
 		}
 	}
 
-	return Result{}
+	return KeyedResult[Key]{}
 }
 
 // Checks that the quantity of data items for each priority in the distribution
 // created by the divider is monotonically non-decreasing as the quantity of data
 // handlers passed to the divider increases.
-func IsMonotonic(divider priodefs.Divider, set []Opts) Result {
+func IsMonotonic[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	set []KeyedOpts[Key],
+) KeyedResult[Key] {
 	for _, opts := range set {
 		if result := isMonotonic(divider, opts); result.Conclusion != nil {
 			return result
 		}
 	}
 
-	return Result{}
+	return KeyedResult[Key]{}
 }
 
-func isMonotonic(divider priodefs.Divider, opts Opts) Result {
-	priorities := reusable.New[uint](len(opts.Priorities))
+func isMonotonic[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	opts KeyedOpts[Key],
+) KeyedResult[Key] {
+	priorities := reusable.New[Key](len(opts.Priorities))
 
 	for combination := range combin.Every(opts.Priorities) {
-		previous := make(map[uint]uint, len(combination))
+		previous := make(map[Key]uint, len(combination))
 
 		for quantity := range safe.Iter(0, opts.Quantity) {
-			actual := make(map[uint]uint, len(combination))
+			actual := make(map[Key]uint, len(combination))
 
 			// Protection against modification of slice by divider
 			copy(priorities.Get(len(combination)), combination)
 
 			if err := divider(quantity, priorities.Get(0), actual); err != nil {
-				result := Result{
+				result := KeyedResult[Key]{
 					Conclusion: ErrDividerFailed,
 					Err:        err,
 					Quantity:   quantity,
@@ -124,7 +136,7 @@ func isMonotonic(divider priodefs.Divider, opts Opts) Result {
 
 			for _, priority := range combination {
 				if actual[priority] < previous[priority] {
-					result := Result{
+					result := KeyedResult[Key]{
 						Conclusion: ErrMonotonicBroken,
 						Quantity:   quantity,
 						Priorities: combination,
@@ -138,14 +150,17 @@ func isMonotonic(divider priodefs.Divider, opts Opts) Result {
 		}
 	}
 
-	return Result{}
+	return KeyedResult[Key]{}
 }
 
 // Finds the minimum quantity of data handlers passed to the divider such that there
 // are no zero values in the distribution created by the divider.
-func FindMinNonFatalQuantity(divider priodefs.Divider, opts Opts) (uint, Result) {
+func FindMinNonFatalQuantity[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	opts KeyedOpts[Key],
+) (uint, KeyedResult[Key]) {
 	for quantity := range safe.Inc(1, opts.Quantity) {
-		params := Opts{
+		params := KeyedOpts[Key]{
 			Quantity:   quantity,
 			Priorities: opts.Priorities,
 		}
@@ -154,10 +169,10 @@ func FindMinNonFatalQuantity(divider priodefs.Divider, opts Opts) (uint, Result)
 			continue
 		}
 
-		return quantity, Result{}
+		return quantity, KeyedResult[Key]{}
 	}
 
-	result := Result{
+	result := KeyedResult[Key]{
 		Conclusion: ErrQuantityNotFound,
 		Quantity:   opts.Quantity,
 		Priorities: opts.Priorities,
@@ -168,9 +183,12 @@ func FindMinNonFatalQuantity(divider priodefs.Divider, opts Opts) (uint, Result)
 
 // Checks that there are no zero values in the distribution created by the divider for
 // the specified quantity of data handlers passed to the divider.
-func IsNonFatalQuantity(divider priodefs.Divider, opts Opts) Result {
-	distribution := make(map[uint]uint, len(opts.Priorities))
-	priorities := reusable.New[uint](len(opts.Priorities))
+func IsNonFatalQuantity[Key comparable](
+	divider priodefs.KeyedDivider[Key],
+	opts KeyedOpts[Key],
+) KeyedResult[Key] {
+	distribution := make(map[Key]uint, len(opts.Priorities))
+	priorities := reusable.New[Key](len(opts.Priorities))
 
 	for combination := range combin.Every(opts.Priorities) {
 		clear(distribution)
@@ -179,7 +197,7 @@ func IsNonFatalQuantity(divider priodefs.Divider, opts Opts) Result {
 		copy(priorities.Get(len(combination)), combination)
 
 		if err := divider(opts.Quantity, priorities.Get(0), distribution); err != nil {
-			result := Result{
+			result := KeyedResult[Key]{
 				Conclusion: ErrDividerFailed,
 				Err:        err,
 				Quantity:   opts.Quantity,
@@ -190,7 +208,7 @@ func IsNonFatalQuantity(divider priodefs.Divider, opts Opts) Result {
 		}
 
 		if !distrib.IsFilled(combination, distribution) {
-			result := Result{
+			result := KeyedResult[Key]{
 				Conclusion: ErrQuantityNotNonFatal,
 				Quantity:   opts.Quantity,
 				Priorities: combination,
@@ -200,7 +218,7 @@ func IsNonFatalQuantity(divider priodefs.Divider, opts Opts) Result {
 		}
 	}
 
-	return Result{}
+	return KeyedResult[Key]{}
 }
 
 // Finds the minimum quantity of data handlers passed to the divider such that the
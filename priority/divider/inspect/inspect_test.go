@@ -3,8 +3,11 @@ package inspect
 import (
 	"math"
 	"testing"
+	"time"
 
+	"github.com/akramarenkov/flow/clock"
 	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/priodefs"
 
 	"github.com/akramarenkov/safe"
 	"github.com/stretchr/testify/require"
@@ -22,6 +25,36 @@ func TestIsQuantityPreserved(t *testing.T) {
 	require.NoError(t, result.Err)
 	require.Zero(t, result.Quantity)
 	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(aging(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(divider.Strict, DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(weighted(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(bounded(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(hierarchy(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
 }
 
 func TestIsQuantityPreservedNegativeConclusion(t *testing.T) {
@@ -81,6 +114,36 @@ func TestIsMonotonic(t *testing.T) {
 	require.NoError(t, result.Err)
 	require.Zero(t, result.Quantity)
 	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(aging(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(divider.Strict, DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(weighted(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(bounded(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(hierarchy(t), DefaultSet())
+	require.NoError(t, result.Conclusion)
+	require.NoError(t, result.Err)
+	require.Zero(t, result.Quantity)
+	require.Empty(t, result.Priorities)
 }
 
 func TestIsMonotonicNegativeConclusion(t *testing.T) {
@@ -205,6 +268,14 @@ func TestIsNonFatalQuantityNegativeConclusion(t *testing.T) {
 	require.NoError(t, result.Err)
 	require.NotZero(t, result.Quantity)
 	require.NotEmpty(t, result.Priorities)
+
+	for _, opts := range DefaultSet() {
+		result := IsNonFatalQuantity(divider.Strict, opts)
+		require.Error(t, result.Conclusion)
+		require.NoError(t, result.Err)
+		require.NotZero(t, result.Quantity)
+		require.NotEmpty(t, result.Priorities)
+	}
 }
 
 func TestFindMinSuitableQuantity(t *testing.T) {
@@ -350,6 +421,41 @@ func TestIsSuitableQuantityNegativeConclusion(t *testing.T) {
 	require.NotEmpty(t, result.Priorities)
 }
 
+func TestKeyed(t *testing.T) {
+	weights := map[string]uint{"gold": 5, "silver": 3, "bronze": 2, "iron": 1}
+
+	weight := func(priority string) uint {
+		return weights[priority]
+	}
+
+	set := []KeyedOpts[string]{
+		{
+			Quantity:   100,
+			Priorities: []string{"gold", "silver", "bronze", "iron"},
+		},
+	}
+
+	result := IsQuantityPreserved(divider.RateBy(weight), set)
+	require.NoError(t, result.Conclusion)
+	require.Empty(t, result.Priorities)
+
+	result = IsMonotonic(divider.RateBy(weight), set)
+	require.NoError(t, result.Conclusion)
+	require.Empty(t, result.Priorities)
+
+	result = IsQuantityPreserved(divider.KeyedFair[string], set)
+	require.NoError(t, result.Conclusion)
+	require.Empty(t, result.Priorities)
+
+	quantity, result := FindMinNonFatalQuantity(divider.RateBy(weight), set[0])
+	require.NoError(t, result.Conclusion)
+	require.Equal(t, uint(4), quantity)
+
+	result = IsNonFatalQuantity(divider.KeyedStrict[string], set[0])
+	require.ErrorIs(t, result.Conclusion, ErrQuantityNotNonFatal)
+	require.Len(t, result.Priorities, 2)
+}
+
 func dividerFailed(quantity uint, _ []uint, _ map[uint]uint) error {
 	if quantity != 0 {
 		return ErrDividerFailed
@@ -429,3 +535,114 @@ func BenchmarkIsSuitableQuantity(b *testing.B) {
 
 	require.NoError(b, result.Conclusion)
 }
+
+// Creates a weighted divider for the default set in which lower priorities have
+// greater weights.
+func weighted(t *testing.T) priodefs.Divider {
+	t.Helper()
+
+	weights := make(map[uint]uint)
+
+	for _, opts := range DefaultSet() {
+		for _, priority := range opts.Priorities {
+			weights[priority] = uint(len(opts.Priorities)) - priority + 1
+		}
+	}
+
+	divide, err := divider.Weighted(weights)
+	require.NoError(t, err)
+
+	return divide
+}
+
+// Creates a divider that divides data items by a fresh aging divider in which all
+// passed priorities have been waiting for several patience periods, so the waiting
+// state is the same in every call and data handlers are reserved for the priorities
+// of every inspected combination.
+func aging(t *testing.T) priodefs.Divider {
+	t.Helper()
+
+	const patience = time.Second
+
+	divide := func(quantity uint, priorities []uint, distribution map[uint]uint) error {
+		clk := clock.NewManual(time.Now())
+
+		aged := divider.Aging(divider.Rate, patience, clk)
+
+		// Priorities start waiting because they receive no data handlers
+		if err := aged(0, priorities, make(map[uint]uint)); err != nil {
+			return err
+		}
+
+		clk.Advance(3 * patience)
+
+		return aged(quantity, priorities, distribution)
+	}
+
+	opts := DefaultSet()[0]
+
+	// Priorities have been waiting for three patience periods, so the reservation
+	// gives three data handlers to each of them, unlike the divider.Rate
+	quantity := 3 * uint(len(opts.Priorities))
+
+	aged := make(map[uint]uint)
+	rated := make(map[uint]uint)
+
+	require.NoError(t, divide(quantity, opts.Priorities, aged))
+	require.NoError(t, divider.Rate(quantity, opts.Priorities, rated))
+	require.NotEqual(t, rated, aged)
+
+	return divide
+}
+
+// Creates a bounded divider for the default set in which the lowest priority has
+// a minimum quantity and the two highest priorities have maximum quantities.
+// Inspections also pass lists consisting only of the bounded from above priorities,
+// for which the surplus is given in excess of the maximum quantities.
+func bounded(t *testing.T) priodefs.Divider {
+	t.Helper()
+
+	opts := DefaultSet()[0]
+
+	divide, err := divider.Bounded(
+		divider.Rate,
+		opts.Quantity,
+		map[uint]uint{opts.Priorities[len(opts.Priorities)-1]: opts.Quantity / 10},
+		map[uint]uint{
+			opts.Priorities[0]: opts.Quantity / 4,
+			opts.Priorities[1]: opts.Quantity / 5,
+		},
+	)
+	require.NoError(t, err)
+
+	return divide
+}
+
+// Creates a hierarchical divider for the default set in which the priorities are
+// divided into two groups shared fairly.
+func hierarchy(t *testing.T) priodefs.Divider {
+	t.Helper()
+
+	opts := DefaultSet()[0]
+
+	groups := map[uint]divider.Group{
+		1: {Divider: divider.Rate, Priorities: make(map[uint]uint)},
+		2: {Divider: divider.Rate, Priorities: make(map[uint]uint)},
+	}
+
+	half := len(opts.Priorities) / 2
+
+	for id, priority := range opts.Priorities {
+		if id < half {
+			groups[2].Priorities[priority-uint(half)] = priority
+			continue
+		}
+
+		groups[1].Priorities[priority] = priority
+	}
+
+	divide, err := divider.Hierarchy(divider.Group{Divider: divider.Fair, Groups: groups})
+	require.NoError(t, err)
+
+	return divide
+}
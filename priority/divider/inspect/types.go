@@ -1,17 +1,23 @@
 package inspect
 
 // Options of inspecting.
-type Opts struct {
+type Opts = KeyedOpts[uint]
+
+// Options of inspecting for priorities of any type.
+type KeyedOpts[Key comparable] struct {
 	// Quantity or maximum quantity of data handlers that will be passed to the divider
 	// when inspecting
 	Quantity uint
 
 	// Priority list on which the divider will be inspected
-	Priorities []uint
+	Priorities []Key
 }
 
 // Inspection result.
-type Result struct {
+type Result = KeyedResult[uint]
+
+// Inspection result for priorities of any type.
+type KeyedResult[Key comparable] struct {
 	// Inspection conclusion. Filled in case of an error occurs in filling the
 	// distribution by the inspected divider, in case of an error occurs in
 	// calculation of the parameters of the obtained distribution or in case
@@ -27,5 +33,5 @@ type Result struct {
 	Quantity uint
 
 	// Priority list for which a negative inspection conclusion was obtained
-	Priorities []uint
+	Priorities []Key
 }
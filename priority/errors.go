@@ -5,9 +5,13 @@ import "errors"
 var (
 	ErrDividerBad               = errors.New("divider creates an incorrect distribution")
 	ErrDividerEmpty             = errors.New("divider was not specified")
+	ErrDisciplineTerminated     = errors.New("discipline is terminated")
 	ErrHandlersQuantityTooSmall = errors.New("quantity of data handlers is too small")
 	ErrHandlersQuantityZero     = errors.New("quantity of data handlers is zero")
 	ErrInputEmpty               = errors.New("input channel was not specified")
 	ErrInputExists              = errors.New("input channel already specified")
+	ErrInputLast                = errors.New("last input channel cannot be removed")
+	ErrInputNotFound            = errors.New("input channel was not found")
 	ErrPriorityZero             = errors.New("zero priority is specified")
+	ErrTicketUnknown            = errors.New("ticket is unknown: already released or reclaimed")
 )
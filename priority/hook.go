@@ -0,0 +1,21 @@
+package priority
+
+import "github.com/akramarenkov/flow/priority/internal/hook"
+
+//nolint:gochecknoinits // Hook can only be set when the package is initialized
+func init() {
+	hook.Stop = haltDiscipline
+}
+
+type halter interface {
+	halt() error
+}
+
+func haltDiscipline(discipline any) error {
+	hlt, is := discipline.(halter)
+	if !is {
+		return hook.ErrDisciplineUnsupported
+	}
+
+	return hlt.halt()
+}
@@ -4,4 +4,28 @@ package priority
 type input[Type any] struct {
 	Channel <-chan Type
 	Closed  bool
+
+	// Data item received from the input channel while waiting for events, but not
+	// yet written to the output channel
+	Held      Type
+	IsHolding bool
+}
+
+// Type of change of the running discipline.
+type changeKind int
+
+const (
+	changeKindAddInput changeKind = iota + 1
+	changeKindHandlersQuantity
+	changeKindRemoveInput
+	changeKindStop
+)
+
+// Request to change the running discipline.
+type change[Key comparable, Type any] struct {
+	Channel          <-chan Type
+	HandlersQuantity uint
+	Kind             changeKind
+	Priority         Key
+	Result           chan error
 }
@@ -8,7 +8,7 @@ import (
 )
 
 // Calculates the total quantity in the distribution for specified priorities.
-func Quantity(priorities []uint, distribution map[uint]uint) (uint, error) {
+func Quantity[Key comparable](priorities []Key, distribution map[Key]uint) (uint, error) {
 	if len(distribution) == 0 {
 		return 0, nil
 	}
@@ -29,7 +29,7 @@ func Quantity(priorities []uint, distribution map[uint]uint) (uint, error) {
 
 // Checks that there are no zero values in the distribution for specified priorities and
 // that the distribution and priority list themselves are not empty.
-func IsFilled(priorities []uint, distribution map[uint]uint) bool {
+func IsFilled[Key comparable](priorities []Key, distribution map[Key]uint) bool {
 	if len(distribution) == 0 {
 		return false
 	}
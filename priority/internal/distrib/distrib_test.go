@@ -8,7 +8,7 @@ import (
 )
 
 func TestQuantity(t *testing.T) {
-	quantity, err := Quantity(nil, nil)
+	quantity, err := Quantity[uint](nil, nil)
 	require.NoError(t, err)
 	require.Equal(t, uint(0), quantity)
 
@@ -50,7 +50,7 @@ func TestQuantity(t *testing.T) {
 }
 
 func TestIsFilled(t *testing.T) {
-	require.False(t, IsFilled(nil, nil))
+	require.False(t, IsFilled[uint](nil, nil))
 	require.False(t, IsFilled(nil, map[uint]uint{}))
 	require.False(t, IsFilled(nil, map[uint]uint{3: 1, 2: 1, 1: 1}))
 	require.False(t, IsFilled([]uint{}, nil))
@@ -0,0 +1,20 @@
+// Internal package that provides other packages of the module with access to the
+// facilities of the priority discipline that are not part of its public API.
+package hook
+
+import "errors"
+
+var (
+	ErrDisciplineUnsupported = errors.New("discipline is not supported")
+)
+
+// Stops the priority discipline from receiving data items from the input channels.
+// Discipline is passed as a pointer to priority.Keyed of any instantiation.
+//
+// Data items already read by the discipline from the input channels are written to
+// the output channel before the stopping. Discipline terminates in normal mode after
+// all data items written to the output channel are released. Repeated call has no
+// effect.
+//
+// Is set by the priority package when it is initialized.
+var Stop func(discipline any) error
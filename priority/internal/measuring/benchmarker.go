@@ -13,6 +13,7 @@ type Benchmarker struct {
 	channels      map[uint]chan uint
 	itemsQuantity map[uint]uint
 	wg            sync.WaitGroup
+	written       sync.WaitGroup
 }
 
 // Creates Benchmarker instance.
@@ -46,6 +47,10 @@ func (bnch *Benchmarker) HandlersQuantity() uint {
 
 // Increases quantity of data items that will be written to the input (for the
 // discipline) channel of the specified priority.
+//
+// If the quantity of data items of the priority remains zero, then its input
+// channel is kept empty and open until the data items of other priorities are
+// written, so the discipline has to wait for data items on sparse inputs.
 func (bnch *Benchmarker) AddItems(priority, quantity uint) {
 	bnch.itemsQuantity[priority] += quantity
 }
@@ -87,15 +92,23 @@ func (bnch *Benchmarker) Play(discipline Discipline[uint]) {
 }
 
 func (bnch *Benchmarker) writers() {
-	for priority := range bnch.itemsQuantity {
+	for priority, quantity := range bnch.itemsQuantity {
 		bnch.wg.Add(1)
 
+		if quantity == 0 {
+			go bnch.idler(priority)
+			continue
+		}
+
+		bnch.written.Add(1)
+
 		go bnch.writer(priority)
 	}
 }
 
 func (bnch *Benchmarker) writer(priority uint) {
 	defer bnch.wg.Done()
+	defer bnch.written.Done()
 	defer close(bnch.channels[priority])
 
 	for item := range bnch.itemsQuantity[priority] {
@@ -103,6 +116,13 @@ func (bnch *Benchmarker) writer(priority uint) {
 	}
 }
 
+func (bnch *Benchmarker) idler(priority uint) {
+	defer bnch.wg.Done()
+	defer close(bnch.channels[priority])
+
+	bnch.written.Wait()
+}
+
 func (bnch *Benchmarker) handlers(discipline Discipline[uint]) {
 	for range bnch.handlersQuantity {
 		bnch.wg.Add(1)
@@ -16,6 +16,25 @@ func TestBenchmarkerUnbuffered(t *testing.T) {
 	testBenchmarker(t, 0)
 }
 
+func TestBenchmarkerSparse(t *testing.T) {
+	bnch, err := NewBenchmarker(100, 0)
+	require.NoError(t, err)
+
+	bnch.AddItems(3, 0)
+	bnch.AddItems(2, 0)
+	bnch.AddItems(1, 1000)
+
+	opts := unmanaged.Opts[uint]{
+		HandlersQuantity: bnch.HandlersQuantity(),
+		Inputs:           bnch.Inputs(),
+	}
+
+	discipline, err := unmanaged.New(opts)
+	require.NoError(t, err)
+
+	bnch.Play(discipline)
+}
+
 func testBenchmarker(t *testing.T, inputCapacity ...uint) {
 	itemsQuantity := uint(1000)
 
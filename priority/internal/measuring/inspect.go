@@ -69,6 +69,10 @@ func isCorrectMeasurements(measurements []Measure, expectedSpans map[uint]span.S
 			return ErrMeasurementsIsIncomplete
 		}
 
+		if _, exists := kinds[KindWritten]; !exists {
+			return ErrMeasurementsIsIncomplete
+		}
+
 		if len(kinds) != measurementsPerDataItem {
 			return ErrUnexpectedMeasureKind
 		}
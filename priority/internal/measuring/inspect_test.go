@@ -21,6 +21,8 @@ func TestIsCorrectMeasurements(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.NoError(t, isCorrectMeasurements(measurements, spans))
@@ -44,6 +46,9 @@ func TestIsCorrectMeasurementsIncomplete(t *testing.T) {
 		{Item: 2, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 2, Kind: KindWritten, Priority: 2},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -54,6 +59,8 @@ func TestIsCorrectMeasurementsIncomplete(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -64,6 +71,8 @@ func TestIsCorrectMeasurementsIncomplete(t *testing.T) {
 		{Item: 0, Kind: KindCompleted, Priority: 3},
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -74,6 +83,8 @@ func TestIsCorrectMeasurementsIncomplete(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 1, Kind: KindWritten, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -92,6 +103,8 @@ func TestIsCorrectMeasurementsDuplicated(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindReceived, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -110,6 +123,8 @@ func TestIsCorrectMeasurementsMixed(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 2},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -121,6 +136,8 @@ func TestIsCorrectMeasurementsMixed(t *testing.T) {
 		{Item: 0, Kind: KindReceived, Priority: 2},
 		{Item: 1, Kind: KindProcessed, Priority: 3},
 		{Item: 0, Kind: KindCompleted, Priority: 2},
+		{Item: 1, Kind: KindWritten, Priority: 3},
+		{Item: 0, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -139,6 +156,8 @@ func TestIsCorrectMeasurementsLost(t *testing.T) {
 		{Item: 2, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 2, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 2, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -158,6 +177,8 @@ func TestIsCorrectMeasurementsLostUnexpected(t *testing.T) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	require.Error(t, isCorrectMeasurements(measurements, spans))
@@ -176,6 +197,8 @@ func BenchmarkIsCorrectMeasurements(b *testing.B) {
 		{Item: 1, Kind: KindReceived, Priority: 2},
 		{Item: 0, Kind: KindProcessed, Priority: 3},
 		{Item: 1, Kind: KindCompleted, Priority: 2},
+		{Item: 0, Kind: KindWritten, Priority: 3},
+		{Item: 1, Kind: KindWritten, Priority: 2},
 	}
 
 	var err error
@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	measurementsPerDataItem = 3
+	measurementsPerDataItem = 4
 )
 
 // Type of one measuring. Specifies the position of the measurement in the
@@ -17,6 +17,7 @@ const (
 	KindCompleted Kind = iota + 1
 	KindProcessed
 	KindReceived
+	KindWritten
 )
 
 // Describes one measuring.
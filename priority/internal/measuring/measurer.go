@@ -278,7 +278,7 @@ func (msr *Measurer) writer(priority, shift uint) {
 	for _, action := range msr.actions[priority] {
 		switch action.Kind {
 		case actionKindWrite, actionKindWriteWithDelay:
-			increased, stop := msr.write(action, msr.channels[priority], sequence)
+			increased, stop := msr.write(action, priority, msr.channels[priority], sequence)
 			if stop {
 				return
 			}
@@ -294,14 +294,30 @@ func (msr *Measurer) writer(priority, shift uint) {
 	}
 }
 
-func (msr *Measurer) write(action action, channel chan uint, sequence uint) (uint, bool) {
+func (msr *Measurer) write(
+	action action,
+	priority uint,
+	channel chan uint,
+	sequence uint,
+) (uint, bool) {
 	for range action.Quantity {
+		// Time spent waiting for a place in the input channel is included in the
+		// latency of the data item
+		written := Measure{
+			Item:     sequence,
+			Kind:     KindWritten,
+			Priority: priority,
+			Time:     time.Since(msr.starter.StartedAt()),
+		}
+
 		select {
 		case <-msr.breaker:
 			return sequence, true
 		case channel <- sequence:
 		}
 
+		msr.measuring <- written
+
 		if action.Kind == actionKindWriteWithDelay {
 			time.Sleep(action.Delay)
 		}
@@ -0,0 +1,163 @@
+package priority
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akramarenkov/flow/priority/priodefs"
+)
+
+// Lease of a data item issued to the data handler.
+type lease[Key comparable] struct {
+	deadline time.Time
+	id       uint64
+	priority Key
+
+	// Neighboring leases in order of issue
+	newer *lease[Key]
+	older *lease[Key]
+}
+
+// Registry of issued leases.
+//
+// Leases are linked in order of their issue, which is also the order of their
+// deadlines because the processing timeout is the same for all data items.
+type leases[Key comparable] struct {
+	timeout time.Duration
+
+	mutex  sync.Mutex
+	issued map[uint64]*lease[Key]
+	lastID uint64
+	newest *lease[Key]
+	oldest *lease[Key]
+}
+
+func newLeases[Key comparable](timeout time.Duration) *leases[Key] {
+	ls := &leases[Key]{
+		timeout: timeout,
+
+		issued: make(map[uint64]*lease[Key]),
+	}
+
+	return ls
+}
+
+// Issues a lease for a data item of the specified priority.
+func (ls *leases[Key]) issue(priority Key, now time.Time) priodefs.Ticket {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	// Integer overflow is practically impossible because even when issuing a
+	// billion leases per second the identifiers will last for hundreds of years
+	ls.lastID++
+
+	issued := &lease[Key]{
+		deadline: now.Add(ls.timeout),
+		id:       ls.lastID,
+		priority: priority,
+	}
+
+	ls.link(issued)
+	ls.issued[issued.id] = issued
+
+	ticket := priodefs.Ticket{
+		Deadline: issued.deadline,
+		ID:       issued.id,
+	}
+
+	return ticket
+}
+
+// Revokes a lease by its identifier and returns its priority. Returns false if
+// the lease was not issued, has already been revoked or has been reclaimed.
+func (ls *leases[Key]) revoke(id uint64) (Key, bool) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	revoked, exists := ls.issued[id]
+	if !exists {
+		var zero Key
+
+		return zero, false
+	}
+
+	ls.unlink(revoked)
+	delete(ls.issued, id)
+
+	return revoked.priority, true
+}
+
+// Revokes the oldest lease of the specified priority. Returns false if there are
+// no issued leases of this priority.
+func (ls *leases[Key]) revokeOldest(priority Key) bool {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	for revoked := ls.oldest; revoked != nil; revoked = revoked.newer {
+		if revoked.priority != priority {
+			continue
+		}
+
+		ls.unlink(revoked)
+		delete(ls.issued, revoked.id)
+
+		return true
+	}
+
+	return false
+}
+
+// Revokes leases whose deadline has passed, appends their priorities to the
+// specified slice and returns the time until the deadline of the oldest remaining
+// lease.
+func (ls *leases[Key]) reclaim(now time.Time, priorities []Key) ([]Key, time.Duration) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	for ls.oldest != nil && !ls.oldest.deadline.After(now) {
+		expired := ls.oldest
+
+		ls.unlink(expired)
+		delete(ls.issued, expired.id)
+
+		priorities = append(priorities, expired.priority)
+	}
+
+	// Any lease issued later will expire no earlier than after the timeout
+	if ls.oldest == nil {
+		return priorities, ls.timeout
+	}
+
+	return priorities, ls.oldest.deadline.Sub(now)
+}
+
+func (ls *leases[Key]) link(issued *lease[Key]) {
+	issued.older = ls.newest
+
+	if ls.newest != nil {
+		ls.newest.newer = issued
+	}
+
+	ls.newest = issued
+
+	if ls.oldest == nil {
+		ls.oldest = issued
+	}
+}
+
+func (ls *leases[Key]) unlink(issued *lease[Key]) {
+	if issued.older != nil {
+		issued.older.newer = issued.newer
+	} else {
+		ls.oldest = issued.newer
+	}
+
+	if issued.newer != nil {
+		issued.newer.older = issued.older
+	} else {
+		ls.newest = issued.older
+	}
+
+	issued.newer = nil
+	issued.older = nil
+}
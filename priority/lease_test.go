@@ -0,0 +1,196 @@
+package priority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/observe"
+	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/priodefs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeases(t *testing.T) {
+	now := time.Now()
+
+	leases := newLeases[uint](time.Second)
+
+	first := leases.issue(1, now)
+	second := leases.issue(2, now.Add(time.Millisecond))
+	third := leases.issue(3, now.Add(2*time.Millisecond))
+
+	require.NotZero(t, first.ID)
+	require.NotEqual(t, first.ID, second.ID)
+	require.NotEqual(t, second.ID, third.ID)
+	require.Equal(t, now.Add(time.Second), first.Deadline)
+
+	priority, revoked := leases.revoke(second.ID)
+	require.True(t, revoked)
+	require.Equal(t, uint(2), priority)
+
+	_, revoked = leases.revoke(second.ID)
+	require.False(t, revoked)
+
+	_, revoked = leases.revoke(0)
+	require.False(t, revoked)
+
+	expired, next := leases.reclaim(now, nil)
+	require.Empty(t, expired)
+	require.Equal(t, time.Second, next)
+
+	expired, next = leases.reclaim(now.Add(time.Second), nil)
+	require.Equal(t, []uint{1}, expired)
+	require.Equal(t, 2*time.Millisecond, next)
+
+	expired, next = leases.reclaim(now.Add(2*time.Second), nil)
+	require.Equal(t, []uint{3}, expired)
+	require.Equal(t, time.Second, next)
+
+	_, revoked = leases.revoke(third.ID)
+	require.False(t, revoked)
+}
+
+func TestDisciplineLeasesDisabled(t *testing.T) {
+	input := make(chan uint, 1)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	prioritized := <-discipline.Output()
+	require.Zero(t, prioritized.Ticket.ID)
+	require.ErrorIs(t, discipline.ReleaseTicket(prioritized.Ticket), ErrTicketUnknown)
+
+	discipline.Release(prioritized.Priority)
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineReleaseTicket(t *testing.T) {
+	input := make(chan uint, 10)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+		Observer:          counters,
+		ProcessingTimeout: time.Minute,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 2
+
+	first := <-discipline.Output()
+	require.NotZero(t, first.Ticket.ID)
+	require.WithinDuration(t, time.Now().Add(time.Minute), first.Ticket.Deadline, time.Second)
+
+	require.NoError(t, discipline.ReleaseTicket(first.Ticket))
+	require.ErrorIs(t, discipline.ReleaseTicket(first.Ticket), ErrTicketUnknown)
+	require.ErrorIs(t, discipline.ReleaseTicket(priodefs.Ticket{}), ErrTicketUnknown)
+
+	second := <-discipline.Output()
+	require.NotEqual(t, first.Ticket.ID, second.Ticket.ID)
+
+	// Release by priority returns the lease of the data item
+	discipline.Release(second.Priority)
+	require.ErrorIs(t, discipline.ReleaseTicket(second.Ticket), ErrTicketUnknown)
+
+	// Data item is not released twice
+	discipline.Release(second.Priority)
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+	require.Equal(t, uint64(2), counters.Released(1))
+	require.Equal(t, uint64(0), counters.Reclaimed(1))
+}
+
+func TestDisciplineReclaim(t *testing.T) {
+	const processingTimeout = 100 * time.Millisecond
+
+	input := make(chan uint, 10)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+		Observer:          counters,
+		ProcessingTimeout: processingTimeout,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+	input <- 2
+
+	startedAt := time.Now()
+
+	// Lease of the first data item is never returned, but the only data handler
+	// becomes vacant after the processing timeout
+	forgotten := <-discipline.Output()
+	second := <-discipline.Output()
+
+	require.GreaterOrEqual(t, time.Since(startedAt), processingTimeout)
+	require.ErrorIs(t, discipline.ReleaseTicket(forgotten.Ticket), ErrTicketUnknown)
+	require.NoError(t, discipline.ReleaseTicket(second.Ticket))
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+	require.Equal(t, uint64(1), counters.Reclaimed(1))
+	require.Equal(t, uint64(2), counters.Released(1))
+	require.Equal(t, uint64(2), counters.Dispatched(1))
+}
+
+func TestDisciplineReclaimTermination(t *testing.T) {
+	input := make(chan uint, 1)
+
+	counters := &observe.Counters{}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+		Observer:          counters,
+		ProcessingTimeout: 100 * time.Millisecond,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	<-discipline.Output()
+
+	close(input)
+
+	// Discipline terminates without the lease being returned
+	require.NoError(t, <-discipline.Err())
+	require.Equal(t, uint64(1), counters.Reclaimed(1))
+}
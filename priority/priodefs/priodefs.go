@@ -1,12 +1,16 @@
 // Data types of the priority discipline.
 package priodefs
 
+import (
+	"time"
+)
+
 // Determines in what quantity data items of the specified priorities are
 // distributed among the specified quantity of data handlers.
 //
 // Priority list passed to the divider is always sorted in descending order,
-// cannot be of zero length and cannot contain a zero priority. The divider must not
-// modify this slice.
+// cannot be of zero length and cannot contain a zero value of priority. The divider
+// must not modify this slice.
 //
 // Distribution map passed to the divider cannot be nil.
 //
@@ -16,10 +20,34 @@ package priodefs
 // Quantity of data items for each priority in the distribution
 // created by the divider must be monotonically non-decreasing as the quantity of data
 // handlers passed to the divider increases.
-type Divider func(quantity uint, priorities []uint, distribution map[uint]uint) error
+type KeyedDivider[Key comparable] func(
+	quantity uint,
+	priorities []Key,
+	distribution map[Key]uint,
+) error
+
+// Divider for priorities of uint type.
+type Divider = KeyedDivider[uint]
 
 // Describes the data item distributed by the priority discipline.
-type Prioritized[Type any] struct {
+type KeyedPrioritized[Key comparable, Type any] struct {
 	Item     Type
-	Priority uint
+	Priority Key
+	// Lease of the data item. Is issued only if the processing timeout is specified
+	// in the options of the discipline
+	Ticket Ticket
+}
+
+// Data item distributed by the priority discipline with priorities of uint type.
+type Prioritized[Type any] = KeyedPrioritized[uint, Type]
+
+// Lease of a data item issued by the priority discipline to the data handler.
+//
+// Data item is considered to be processed by the data handler until the ticket is
+// returned to the discipline or until the deadline is passed.
+type Ticket struct {
+	// Time after which the lease can be reclaimed by the discipline
+	Deadline time.Time
+	// Unique identifier of the lease. Zero value means that the lease is not issued
+	ID uint64
 }
@@ -3,26 +3,55 @@
 package priority
 
 import (
+	"cmp"
+	"errors"
+	"reflect"
 	"slices"
 	"time"
 
+	"github.com/akramarenkov/flow/observe"
 	"github.com/akramarenkov/flow/priority/internal/distrib"
 	"github.com/akramarenkov/flow/priority/priodefs"
 )
 
 const (
-	defaultIdleDelay = 1 * time.Nanosecond
+	// Quantity of select cases that are not input channels: release, changes and
+	// stats requests
+	eventCasesQuantity = 3
 )
 
 // Options of the created discipline.
-type Opts[Type any] struct {
+//
+// Priorities can be of any ordered type, data items of higher priorities are those
+// whose priorities are greater according to the [cmp.Compare] function.
+//
+// Note that the natural order of the priority type is always the priority order
+// and it cannot be redefined. For example, for the string priorities "gold" and
+// "silver", the "silver" is the higher priority because it is greater
+// lexicographically. The order determines in which order the priorities are passed
+// to the divider and thus which of them are preferred by dividers that depend on
+// it, such as divider.KeyedStrict or divider.KeyedFair. If the natural order does
+// not match the desired one, use a type whose values are ordered as desired, for
+// example, an integer type with named constants. Dividers that use weights, such as
+// divider.RateBy or divider.Weighted, distribute data items in ratio to the weights,
+// so for them the order affects only the distribution of the division remainder.
+type KeyedOpts[Key cmp.Ordered, Type any] struct {
 	// Determines in what quantity data items distributed among data handlers
 	//
 	// For equaling use divider.Fair divider, for prioritization use divider.Rate
-	// divider or custom divider
-	Divider priodefs.Divider
-
-	// Quantity of data handlers between which data items are distributed
+	// divider or custom divider. To prevent starvation of low priorities wrap the
+	// divider by divider.Aging and set the PendingOnly option. To divide data
+	// handlers among groups of input channels, for example tenants, and then inside
+	// each group by its own divider use divider.Hierarchy. For priorities of other
+	// than uint type use divider.KeyedFair, divider.RateBy, divider.Weighted or
+	// divider.KeyedHierarchy dividers
+	Divider priodefs.KeyedDivider[Key]
+
+	// Quantity of data handlers between which data items are distributed. Also
+	// determines the capacity of the output channel
+	//
+	// Can be changed while the discipline is running by the
+	// [Discipline.SetHandlersQuantity] method
 	HandlersQuantity uint
 
 	// Input channels of data items. For terminate the discipline it is necessary and
@@ -30,13 +59,54 @@ type Opts[Type any] struct {
 	// buffered for performance reasons. Optimal capacity is equal to the quantity of
 	// data handlers
 	//
-	// Map key is a value of priority. Zero priority is not allowed
-	Inputs map[uint]<-chan Type
+	// Map key is a value of priority. Zero value of priority is not allowed
+	//
+	// Input channels can be added and removed while the discipline is running by
+	// the [Discipline.AddInput] and [Discipline.RemoveInput] methods
+	Inputs map[Key]<-chan Type
+
+	// Observer of the internal events of the discipline. The discipline reports
+	// writing of data items to the output channel by the OnDispatch method, receiving
+	// of releases by the OnRelease method and errors of the divider by the
+	// OnDividerError method. If not specified, events are not reported
+	Observer observe.PriorityObserver[Key]
+
+	// By default, data items are distributed among all priorities in accordance with
+	// the strategic distribution created by the divider for all input channels, and
+	// the divider must not leave any priority without data handlers. If the
+	// PendingOnly is set to true, then at each stage of input/output vacant data
+	// handlers are divided only among priorities whose input channels have pending
+	// data items, and priorities without data handlers in the strategic distribution
+	// are allowed
+	//
+	// Data items are pending if they are buffered in the input channel or have
+	// already been received from it by the discipline. Thus, an unbuffered input
+	// channel is considered to have no more than one pending data item
+	//
+	// Required for the divider.Strict divider
+	PendingOnly bool
+
+	// Maximum duration of processing of a data item by the data handler. If it is
+	// specified, then a lease with a unique identifier and a deadline is issued with
+	// every data item written to the output channel and data handlers should return
+	// it by the [Discipline.ReleaseTicket] method instead of the
+	// [Discipline.Release] method
+	//
+	// Leases that have not been returned before the deadline are reclaimed by the
+	// discipline as if the data items had been released, so a stuck or lost data
+	// handler does not reduce the quantity of data handlers forever. Reclamation is
+	// reported by the OnReclaim method of the observer, if it implements the
+	// observe.ReclaimObserver interface, followed by the OnRelease method. A zero or
+	// negative value means that leases are not issued
+	ProcessingTimeout time.Duration
 }
 
+// Options of the created discipline with priorities of uint type.
+type Opts[Type any] = KeyedOpts[uint, Type]
+
 // Adds an input channel with the specified priority to the inputs map.
-func (opts *Opts[Type]) AddInput(priority uint, channel <-chan Type) error {
-	if priority == 0 {
+func (opts *KeyedOpts[Key, Type]) AddInput(priority Key, channel <-chan Type) error {
+	if isZero(priority) {
 		return ErrPriorityZero
 	}
 
@@ -45,7 +115,7 @@ func (opts *Opts[Type]) AddInput(priority uint, channel <-chan Type) error {
 	}
 
 	if opts.Inputs == nil {
-		opts.Inputs = make(map[uint]<-chan Type)
+		opts.Inputs = make(map[Key]<-chan Type)
 	}
 
 	if stored := opts.Inputs[priority]; stored != nil {
@@ -57,7 +127,7 @@ func (opts *Opts[Type]) AddInput(priority uint, channel <-chan Type) error {
 	return nil
 }
 
-func (opts Opts[Type]) isInputEmpty() error {
+func (opts KeyedOpts[Key, Type]) isInputEmpty() error {
 	if len(opts.Inputs) == 0 {
 		return ErrInputEmpty
 	}
@@ -71,7 +141,7 @@ func (opts Opts[Type]) isInputEmpty() error {
 	return nil
 }
 
-func (opts Opts[Type]) isValid() error {
+func (opts KeyedOpts[Key, Type]) isValid() error {
 	if opts.Divider == nil {
 		return ErrDividerEmpty
 	}
@@ -84,15 +154,27 @@ func (opts Opts[Type]) isValid() error {
 		return err
 	}
 
-	if channel := opts.Inputs[0]; channel != nil {
+	var zero Key
+
+	if channel := opts.Inputs[zero]; channel != nil {
 		return ErrPriorityZero
 	}
 
 	return nil
 }
 
-func (opts Opts[Type]) disciplineInputs() map[uint]input[Type] {
-	inputs := make(map[uint]input[Type], len(opts.Inputs))
+func (opts KeyedOpts[Key, Type]) normalize() KeyedOpts[Key, Type] {
+	if opts.ProcessingTimeout < 0 {
+		opts.ProcessingTimeout = 0
+	}
+
+	opts.Observer = observe.EnsurePriority(opts.Observer)
+
+	return opts
+}
+
+func (opts KeyedOpts[Key, Type]) disciplineInputs() map[Key]input[Type] {
+	inputs := make(map[Key]input[Type], len(opts.Inputs))
 
 	for priority, channel := range opts.Inputs {
 		input := input[Type]{
@@ -105,83 +187,130 @@ func (opts Opts[Type]) disciplineInputs() map[uint]input[Type] {
 	return inputs
 }
 
+// Priority discipline with priorities of uint type.
+type Discipline[Type any] = Keyed[uint, Type]
+
 // Priority discipline.
-type Discipline[Type any] struct {
-	opts Opts[Type]
+type Keyed[Key cmp.Ordered, Type any] struct {
+	opts KeyedOpts[Key, Type]
 
-	inputs  map[uint]input[Type]
-	output  chan priodefs.Prioritized[Type]
-	release chan uint
+	changes chan change[Key, Type]
+	done    chan struct{}
+	inputs  map[Key]input[Type]
+	output  chan priodefs.KeyedPrioritized[Key, Type]
+	release chan Key
+	stats   chan chan KeyedStats[Key]
+
+	// Issued leases of data items. Is nil if the processing timeout is not specified
+	leases *leases[Key]
+
+	// Cumulative quantities of written to the output channel and released data items
+	dispatches map[Key]uint64
+	releases   map[Key]uint64
+	// Snapshot of the state of the terminated discipline
+	final KeyedStats[Key]
 
 	// Priority list corresponding to all input channels - main priority list
-	priorities []uint
+	priorities []Key
+	// Priority list whose input channels have been removed, but whose data items
+	// have not yet been released
+	retired []Key
+	// Priority list whose input channels have pending data items
+	pending []Key
 	// Priority list whose actual distribution did not reach strategic
-	unachieved []uint
+	unachieved []Key
 	// Priority list whose actual distribution did not reach operative
-	unreached []uint
+	unreached []Key
 	// Priority list from whose channels it managed to get all data items at
 	// input/output stage for priorities from the unachieved list and, since the
 	// unachieved list may not be complete with respect to the main priority list
 	// then, at any previous input/output stages - interim main priority list
-	useful []uint
+	useful []Key
 
 	// Actual distribution of data items
-	actual map[uint]uint
+	actual map[Key]uint
 	// Distribution of data items filled by useful priority list and total quantity of
 	// data handlers - interim strategic distribution
-	operative map[uint]uint
+	operative map[Key]uint
 	// Distribution of data items filled by main priority list and total quantity of
 	// data handlers
-	strategic map[uint]uint
+	strategic map[Key]uint
 	// Distribution on whose quantities input/output is performed
-	tactic map[uint]uint
+	tactic map[Key]uint
+
+	// Select cases and corresponding priorities used when waiting for events
+	cases      []reflect.SelectCase
+	casesPrios []Key
+	// Indicates that the discipline has been changed at the current stage of
+	// input/output
+	changed bool
+	// Indicates that receiving data items from the input channels has been stopped
+	stopped bool
 
 	err chan error
 }
 
-// Creates and runs discipline.
+// Creates and runs discipline with priorities of uint type.
 func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
+	return NewKeyed(opts)
+}
+
+// Creates and runs discipline.
+func NewKeyed[Key cmp.Ordered, Type any](opts KeyedOpts[Key, Type]) (*Keyed[Key, Type], error) {
 	if err := opts.isValid(); err != nil {
 		return nil, err
 	}
 
+	opts = opts.normalize()
+
 	inputs, priorities, strategic, err := prepare(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	dsc := &Discipline[Type]{
+	dsc := &Keyed[Key, Type]{
 		opts: opts,
 
+		changes: make(chan change[Key, Type]),
+		done:    make(chan struct{}),
 		inputs:  inputs,
-		output:  make(chan priodefs.Prioritized[Type], opts.HandlersQuantity),
-		release: make(chan uint, opts.HandlersQuantity),
+		output:  make(chan priodefs.KeyedPrioritized[Key, Type], opts.HandlersQuantity),
+		release: make(chan Key, opts.HandlersQuantity),
+		stats:   make(chan chan KeyedStats[Key]),
+
+		dispatches: make(map[Key]uint64),
+		releases:   make(map[Key]uint64),
 
 		priorities: priorities,
 
-		actual:    make(map[uint]uint),
-		operative: make(map[uint]uint),
+		actual:    make(map[Key]uint),
+		operative: make(map[Key]uint),
 		strategic: strategic,
-		tactic:    make(map[uint]uint),
+		tactic:    make(map[Key]uint),
 
 		err: make(chan error, 1),
 	}
 
+	if opts.ProcessingTimeout != 0 {
+		dsc.leases = newLeases[Key](opts.ProcessingTimeout)
+		go dsc.reclaim()
+	}
+
 	go dsc.main()
 
 	return dsc, nil
 }
 
-func prepare[Type any](opts Opts[Type]) (
-	map[uint]input[Type],
-	[]uint,
-	map[uint]uint,
+func prepare[Key cmp.Ordered, Type any](opts KeyedOpts[Key, Type]) (
+	map[Key]input[Type],
+	[]Key,
+	map[Key]uint,
 	error,
 ) {
 	inputs := opts.disciplineInputs()
 
-	priorities := make([]uint, 0, len(inputs))
-	strategic := make(map[uint]uint, len(inputs))
+	priorities := make([]Key, 0, len(inputs))
+	strategic := make(map[Key]uint, len(inputs))
 
 	for priority := range inputs {
 		priorities = append(priorities, priority)
@@ -189,22 +318,38 @@ func prepare[Type any](opts Opts[Type]) (
 
 	slices.SortFunc(priorities, Compare)
 
+	if err := fillStrategic(opts, priorities, strategic); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return inputs, priorities, strategic, nil
+}
+
+func fillStrategic[Key cmp.Ordered, Type any](
+	opts KeyedOpts[Key, Type],
+	priorities []Key,
+	strategic map[Key]uint,
+) error {
 	err := divide(opts.Divider, opts.HandlersQuantity, priorities, strategic)
 	if err != nil {
-		return nil, nil, nil, err
+		return err
+	}
+
+	if opts.PendingOnly {
+		return nil
 	}
 
 	if !distrib.IsFilled(priorities, strategic) {
-		return nil, nil, nil, ErrHandlersQuantityTooSmall
+		return ErrHandlersQuantityTooSmall
 	}
 
-	return inputs, priorities, strategic, nil
+	return nil
 }
 
 // Returns output channel.
 //
 // If this channel is closed, it means that the discipline is terminated.
-func (dsc *Discipline[Type]) Output() <-chan priodefs.Prioritized[Type] {
+func (dsc *Keyed[Key, Type]) Output() <-chan priodefs.KeyedPrioritized[Key, Type] {
 	return dsc.output
 }
 
@@ -212,10 +357,149 @@ func (dsc *Discipline[Type]) Output() <-chan priodefs.Prioritized[Type] {
 // data item.
 //
 // Handlers must call this method after the current data item has been processed.
-func (dsc *Discipline[Type]) Release(priority uint) {
+//
+// If the processing timeout is specified in the options, then the
+// [Discipline.ReleaseTicket] method should be used instead. In this case this method
+// returns the oldest lease of the specified priority, and if all leases of this
+// priority have already been returned or reclaimed, then it does nothing, so that
+// the data item is not released twice: by the handler and by the reclamation of
+// its lease.
+func (dsc *Keyed[Key, Type]) Release(priority Key) {
+	if dsc.leases != nil && !dsc.leases.revokeOldest(priority) {
+		return
+	}
+
 	dsc.release <- priority
 }
 
+// Returns the lease of the current data item to the discipline and marks that the
+// handler is ready to receive new data item.
+//
+// Should be used instead of the [Discipline.Release] method if the processing
+// timeout is specified in the options. Returns [ErrTicketUnknown] if the lease has already
+// been returned or has been reclaimed due to expiration of the processing timeout,
+// in which case the data handler should not assume that the discipline has waited
+// for the data item to be processed.
+func (dsc *Keyed[Key, Type]) ReleaseTicket(ticket priodefs.Ticket) error {
+	if dsc.leases == nil {
+		return ErrTicketUnknown
+	}
+
+	priority, revoked := dsc.leases.revoke(ticket.ID)
+	if !revoked {
+		return ErrTicketUnknown
+	}
+
+	dsc.release <- priority
+
+	return nil
+}
+
+// Adds an input channel with the specified priority to the running discipline.
+//
+// Strategic distribution is recalculated by the divider taking into account the
+// added priority. If the divider returns an error or the quantity of data handlers
+// is too small for the new priority list, then the input channel is not added and
+// the error is returned.
+//
+// Change is applied by the discipline between the stages of input/output, so this
+// method blocks until the change is applied.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Keyed[Key, Type]) AddInput(priority Key, channel <-chan Type) error {
+	if isZero(priority) {
+		return ErrPriorityZero
+	}
+
+	if channel == nil {
+		return ErrInputEmpty
+	}
+
+	chg := change[Key, Type]{
+		Channel:  channel,
+		Kind:     changeKindAddInput,
+		Priority: priority,
+	}
+
+	return dsc.change(chg)
+}
+
+// Removes an input channel with the specified priority from the running discipline.
+//
+// Strategic distribution is recalculated by the divider without taking into account
+// the removed priority. If the divider returns an error, then the input channel is
+// not removed and the error is returned. The last input channel cannot be removed,
+// for terminate the discipline close the input channels.
+//
+// Data items of the removed priority that have already been written to the output
+// channel must be released by the [Discipline.Release] method as usual. Data item
+// already read by the discipline from the removed input channel is written to the
+// output channel before the removal. Data items remaining in the removed input
+// channel are not read by the discipline.
+//
+// Change is applied by the discipline between the stages of input/output, so this
+// method blocks until the change is applied.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Keyed[Key, Type]) RemoveInput(priority Key) error {
+	chg := change[Key, Type]{
+		Kind:     changeKindRemoveInput,
+		Priority: priority,
+	}
+
+	return dsc.change(chg)
+}
+
+// Changes the quantity of data handlers of the running discipline.
+//
+// Strategic distribution is recalculated by the divider for the new quantity of
+// data handlers. If the divider returns an error or the new quantity of data
+// handlers is too small for the current priority list, then the quantity of data
+// handlers is not changed and the error is returned.
+//
+// When the quantity is increased, the additional data handlers are used
+// immediately. When the quantity is decreased, the change is applied only after the
+// quantity of data items being processed becomes no greater than the new quantity of
+// data handlers, so this method blocks until enough data items are released.
+//
+// Capacity of the output channel is not changed. Handlers exceeding it are served
+// correctly, but possibly with less performance.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Keyed[Key, Type]) SetHandlersQuantity(quantity uint) error {
+	if quantity == 0 {
+		return ErrHandlersQuantityZero
+	}
+
+	chg := change[Key, Type]{
+		HandlersQuantity: quantity,
+		Kind:             changeKindHandlersQuantity,
+	}
+
+	return dsc.change(chg)
+}
+
+// Stops receiving data items from the input channels, is called via hook.Stop.
+func (dsc *Keyed[Key, Type]) halt() error {
+	chg := change[Key, Type]{
+		Kind: changeKindStop,
+	}
+
+	return dsc.change(chg)
+}
+
+func (dsc *Keyed[Key, Type]) change(chg change[Key, Type]) error {
+	chg.Result = make(chan error, 1)
+
+	select {
+	case <-dsc.done:
+		return ErrDisciplineTerminated
+	case dsc.changes <- chg:
+	}
+
+	return <-chg.Result
+}
+
 // Returns a channel with errors. If an error occurs (the value from the channel
 // is not equal to nil) the discipline terminates its work.
 //
@@ -226,68 +510,437 @@ func (dsc *Discipline[Type]) Release(priority uint) {
 // divider is working correctly and the configuration used will not cause an error
 // in it, then you are not obliged to read from this channel and you are not obliged
 // to check the received value.
-func (dsc *Discipline[Type]) Err() <-chan error {
+func (dsc *Keyed[Key, Type]) Err() <-chan error {
 	return dsc.err
 }
 
-func (dsc *Discipline[Type]) main() {
+// Returns a snapshot of the state of the discipline: distributions of data items,
+// closing state of input channels, quantity of vacant data handlers and cumulative
+// quantities of written to the output channel and released data items.
+//
+// Snapshot is gathered by the discipline goroutine between its actions, so it is
+// consistent. After the discipline is terminated, the final snapshot is returned.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Keyed[Key, Type]) Stats() KeyedStats[Key] {
+	request := make(chan KeyedStats[Key], 1)
+
+	select {
+	case <-dsc.done:
+		return dsc.final
+	case dsc.stats <- request:
+	}
+
+	return <-request
+}
+
+func (dsc *Keyed[Key, Type]) main() {
+	defer close(dsc.done)
 	defer close(dsc.err)
 	defer close(dsc.output)
 	defer close(dsc.release)
+	defer dsc.finalize()
 
 	if err := dsc.loop(); err != nil {
 		dsc.err <- err
 	}
 }
 
-func (dsc *Discipline[Type]) loop() error {
+func (dsc *Keyed[Key, Type]) loop() error {
 	defer dsc.waitFullReleased()
 
 	for {
+		dsc.changed = false
+
+		dsc.applyChanges()
+		dsc.answerAll()
+
 		distributed, err := dsc.distribute()
 		if err != nil {
 			return err
 		}
 
-		dsc.collectReleases()
+		released := dsc.collectReleases()
+		dsc.forgetRetired()
 
-		if distributed == 0 {
-			if dsc.isInputsClosed() {
-				return nil
-			}
+		// Any of these events can change the result of the next distribution
+		if distributed != 0 || released != 0 || dsc.changed {
+			continue
+		}
 
-			time.Sleep(defaultIdleDelay)
+		if dsc.isInputsClosed() {
+			return nil
 		}
+
+		dsc.waitEvent()
+	}
+}
+
+// Waits for an event that can change the result of the distribution: receiving of a
+// data item from any input channel, release of a data item or change of the
+// discipline. Thus, in the absence of data items, the discipline does not consume
+// processor time.
+//
+// Received data item is held in the input descriptor until it is written to the
+// output channel at the next stages of input/output.
+func (dsc *Keyed[Key, Type]) waitEvent() {
+	dsc.prepareCases()
+
+	chosen, received, opened := reflect.Select(dsc.cases)
+
+	switch chosen {
+	case 0:
+		// Release channel is never closed while the discipline is running
+		priority, _ := received.Interface().(Key)
+		dsc.released(priority)
+	case 1:
+		chg, _ := received.Interface().(change[Key, Type])
+		dsc.apply(chg)
+	case 2:
+		request, _ := received.Interface().(chan KeyedStats[Key])
+		dsc.answer(request)
+	default:
+		priority := dsc.casesPrios[chosen-eventCasesQuantity]
+
+		if !opened {
+			dsc.markInputAsClosed(priority)
+			return
+		}
+
+		// Zero value of the reflect.Value type is returned for nil value of
+		// interface type, so the comma-ok form is used
+		item, _ := received.Interface().(Type)
+
+		dsc.hold(priority, item)
+	}
+}
+
+func (dsc *Keyed[Key, Type]) prepareCases() {
+	dsc.cases = append(
+		dsc.cases[:0],
+		reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(dsc.release),
+		},
+		reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(dsc.changes),
+		},
+		reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(dsc.stats),
+		},
+	)
+
+	dsc.casesPrios = dsc.casesPrios[:0]
+
+	for _, priority := range dsc.priorities {
+		input := dsc.inputs[priority]
+
+		if input.Closed || input.IsHolding {
+			continue
+		}
+
+		dsc.cases = append(
+			dsc.cases,
+			reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(input.Channel),
+			},
+		)
+
+		dsc.casesPrios = append(dsc.casesPrios, priority)
 	}
 }
 
-func (dsc *Discipline[Type]) waitFullReleased() {
+func (dsc *Keyed[Key, Type]) hold(priority Key, item Type) {
+	input := dsc.inputs[priority]
+
+	input.Held = item
+	input.IsHolding = true
+
+	dsc.inputs[priority] = input
+}
+
+func (dsc *Keyed[Key, Type]) unhold(priority Key) Type {
+	input := dsc.inputs[priority]
+
+	item := input.Held
+
+	var zero Type
+
+	input.Held = zero
+	input.IsHolding = false
+
+	dsc.inputs[priority] = input
+
+	return item
+}
+
+func (dsc *Keyed[Key, Type]) waitFullReleased() {
 	for !dsc.isFullyReleased() {
 		dsc.waitRelease()
 	}
 }
 
-func (dsc *Discipline[Type]) isFullyReleased() bool {
+func (dsc *Keyed[Key, Type]) isFullyReleased() bool {
 	for _, priority := range dsc.priorities {
 		if dsc.actual[priority] != 0 {
 			return false
 		}
 	}
 
+	for _, priority := range dsc.retired {
+		if dsc.actual[priority] != 0 {
+			return false
+		}
+	}
+
 	return true
 }
 
-func (dsc *Discipline[Type]) collectReleases() {
-	for range len(dsc.release) {
+func (dsc *Keyed[Key, Type]) applyChanges() {
+	for {
+		select {
+		case chg := <-dsc.changes:
+			dsc.apply(chg)
+		default:
+			return
+		}
+	}
+}
+
+func (dsc *Keyed[Key, Type]) apply(chg change[Key, Type]) {
+	dsc.changed = true
+
+	chg.Result <- dsc.applyChange(chg)
+}
+
+func (dsc *Keyed[Key, Type]) applyChange(chg change[Key, Type]) error {
+	switch chg.Kind {
+	case changeKindAddInput:
+		return dsc.addInput(chg.Priority, chg.Channel)
+	case changeKindHandlersQuantity:
+		return dsc.setHandlersQuantity(chg.HandlersQuantity)
+	case changeKindRemoveInput:
+		return dsc.removeInput(chg.Priority)
+	case changeKindStop:
+		return dsc.stop()
+	}
+
+	return nil
+}
+
+func (dsc *Keyed[Key, Type]) addInput(priority Key, channel <-chan Type) error {
+	// Input channels are not read after the stopping
+	if dsc.stopped {
+		return ErrDisciplineTerminated
+	}
+
+	if _, exists := dsc.inputs[priority]; exists {
+		return ErrInputExists
+	}
+
+	priorities := make([]Key, 0, len(dsc.priorities)+1)
+	priorities = append(priorities, dsc.priorities...)
+	priorities = append(priorities, priority)
+
+	slices.SortFunc(priorities, Compare)
+
+	strategic := make(map[Key]uint, len(priorities))
+
+	if err := dsc.divided(fillStrategic(dsc.opts, priorities, strategic)); err != nil {
+		return err
+	}
+
+	dsc.inputs[priority] = input[Type]{
+		Channel: channel,
+	}
+
+	dsc.priorities = priorities
+	dsc.strategic = strategic
+
+	// Data items of the re-added priority that are still being processed are
+	// counted by the main priority list
+	dsc.retired = slices.DeleteFunc(dsc.retired, func(retired Key) bool {
+		return retired == priority
+	})
+
+	return nil
+}
+
+func (dsc *Keyed[Key, Type]) removeInput(priority Key) error {
+	if _, exists := dsc.inputs[priority]; !exists {
+		return ErrInputNotFound
+	}
+
+	if len(dsc.inputs) == 1 {
+		return ErrInputLast
+	}
+
+	priorities := slices.DeleteFunc(
+		slices.Clone(dsc.priorities),
+		func(removed Key) bool {
+			return removed == priority
+		},
+	)
+
+	strategic := make(map[Key]uint, len(priorities))
+
+	if err := dsc.divided(fillStrategic(dsc.opts, priorities, strategic)); err != nil {
+		return err
+	}
+
+	// Data item already received from the removed input channel must not be lost
+	dsc.flush(priority)
+
+	delete(dsc.inputs, priority)
+	delete(dsc.operative, priority)
+	delete(dsc.tactic, priority)
+
+	dsc.priorities = priorities
+	dsc.strategic = strategic
+
+	if dsc.actual[priority] == 0 {
+		delete(dsc.actual, priority)
+		return nil
+	}
+
+	dsc.retired = append(dsc.retired, priority)
+
+	return nil
+}
+
+func (dsc *Keyed[Key, Type]) stop() error {
+	dsc.stopped = true
+
+	for _, priority := range dsc.priorities {
+		// Data items already received from the input channels must not be lost
+		dsc.flush(priority)
+		dsc.markInputAsClosed(priority)
+	}
+
+	return nil
+}
+
+// Writes the held data item of the specified priority to the output channel.
+func (dsc *Keyed[Key, Type]) flush(priority Key) {
+	if !dsc.inputs[priority].IsHolding {
+		return
+	}
+
+	for dsc.vacantHandlers() == 0 {
+		dsc.waitRelease()
+	}
+
+	dsc.write(
+		priodefs.KeyedPrioritized[Key, Type]{
+			Item:     dsc.unhold(priority),
+			Priority: priority,
+		},
+	)
+
+	dsc.actual[priority]++
+}
+
+func (dsc *Keyed[Key, Type]) setHandlersQuantity(quantity uint) error {
+	opts := dsc.opts
+	opts.HandlersQuantity = quantity
+
+	strategic := make(map[Key]uint, len(dsc.priorities))
+
+	if err := dsc.divided(fillStrategic(opts, dsc.priorities, strategic)); err != nil {
+		return err
+	}
+
+	// Quantity of vacant data handlers cannot be calculated while the quantity of
+	// busy data handlers is greater than the total quantity of data handlers
+	for dsc.busyHandlers() > quantity {
+		dsc.waitRelease()
+	}
+
+	dsc.opts.HandlersQuantity = quantity
+	dsc.strategic = strategic
+
+	return nil
+}
+
+// Forgets retired priorities whose data items have been fully released.
+func (dsc *Keyed[Key, Type]) forgetRetired() {
+	if len(dsc.retired) == 0 {
+		return
+	}
+
+	dsc.retired = slices.DeleteFunc(dsc.retired, func(priority Key) bool {
+		if dsc.actual[priority] != 0 {
+			return false
+		}
+
+		delete(dsc.actual, priority)
+
+		return true
+	})
+}
+
+func (dsc *Keyed[Key, Type]) collectReleases() int {
+	released := len(dsc.release)
+
+	for range released {
 		dsc.waitRelease()
 	}
+
+	return released
 }
 
-func (dsc *Discipline[Type]) waitRelease() {
-	dsc.actual[<-dsc.release]--
+func (dsc *Keyed[Key, Type]) waitRelease() {
+	for {
+		select {
+		case priority := <-dsc.release:
+			dsc.released(priority)
+			return
+		case request := <-dsc.stats:
+			dsc.answer(request)
+		}
+	}
 }
 
-func (dsc *Discipline[Type]) isInputsClosed() bool {
+func (dsc *Keyed[Key, Type]) released(priority Key) {
+	dsc.actual[priority]--
+	dsc.releases[priority]++
+
+	dsc.opts.Observer.OnRelease(priority)
+}
+
+func (dsc *Keyed[Key, Type]) dispatched(priority Key) {
+	dsc.dispatches[priority]++
+
+	dsc.opts.Observer.OnDispatch(priority)
+}
+
+func (dsc *Keyed[Key, Type]) finalize() {
+	dsc.final = dsc.snapshot()
+}
+
+// Waits for a release of data item or for a change of the discipline. Changes must be
+// applied while waiting because all data handlers can be busy indefinitely.
+//
+// Returns true if the discipline has been changed and therefore the current stage
+// of distribution must be started over.
+func (dsc *Keyed[Key, Type]) waitReleaseOrChange() bool {
+	select {
+	case priority := <-dsc.release:
+		dsc.released(priority)
+		return false
+	case chg := <-dsc.changes:
+		dsc.apply(chg)
+		return true
+	case request := <-dsc.stats:
+		dsc.answer(request)
+		return false
+	}
+}
+
+func (dsc *Keyed[Key, Type]) isInputsClosed() bool {
 	for _, input := range dsc.inputs {
 		if !input.Closed {
 			return false
@@ -297,13 +950,22 @@ func (dsc *Discipline[Type]) isInputsClosed() bool {
 	return true
 }
 
-func (dsc *Discipline[Type]) distribute() (uint, error) {
+func (dsc *Keyed[Key, Type]) distribute() (uint, error) {
+	if dsc.opts.PendingOnly {
+		return dsc.distributePending()
+	}
+
 	distributed := uint(0)
 
-	if err := dsc.waitFillingUnachieved(); err != nil {
+	filled, err := dsc.waitFillingUnachieved()
+	if err != nil {
 		return distributed, err
 	}
 
+	if !filled {
+		return distributed, nil
+	}
+
 	distributed += dsc.transfer(dsc.unachieved)
 
 	proceed, err := dsc.fillOperative()
@@ -315,32 +977,81 @@ func (dsc *Discipline[Type]) distribute() (uint, error) {
 		return distributed, nil
 	}
 
-	if err := dsc.waitFillingUnreached(); err != nil {
+	filled, err = dsc.waitFillingUnreached()
+	if err != nil {
 		return distributed, err
 	}
 
+	if !filled {
+		return distributed, nil
+	}
+
 	distributed += dsc.transfer(dsc.unreached)
 
 	return distributed, nil
 }
 
-func (dsc *Discipline[Type]) waitFillingUnachieved() error {
+// Divides vacant data handlers among priorities with pending data items. Data
+// handlers that remain vacant because the input channel has run out of data items
+// are divided at the next stage of input/output among the remaining priorities.
+func (dsc *Keyed[Key, Type]) distributePending() (uint, error) {
+	vacant := dsc.vacantHandlers()
+
+	if vacant == 0 {
+		return 0, nil
+	}
+
+	dsc.preparePending()
+
+	if len(dsc.pending) == 0 {
+		return 0, nil
+	}
+
+	dsc.resetTactic()
+
+	err := divide(dsc.opts.Divider, vacant, dsc.pending, dsc.tactic)
+	if err := dsc.divided(err); err != nil {
+		return 0, err
+	}
+
+	return dsc.transfer(dsc.pending), nil
+}
+
+func (dsc *Keyed[Key, Type]) preparePending() {
+	dsc.pending = dsc.pending[:0]
+
+	for _, priority := range dsc.priorities {
+		input := dsc.inputs[priority]
+
+		if input.Closed {
+			continue
+		}
+
+		if input.IsHolding || len(input.Channel) != 0 {
+			dsc.pending = append(dsc.pending, priority)
+		}
+	}
+}
+
+func (dsc *Keyed[Key, Type]) waitFillingUnachieved() (bool, error) {
 	for {
 		filled, err := dsc.fillUnachieved()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if filled {
-			return nil
+			return true, nil
 		}
 
-		dsc.waitRelease()
+		if dsc.waitReleaseOrChange() {
+			return false, nil
+		}
 	}
 }
 
 // Fills tactical distribution for unachieved priorities.
-func (dsc *Discipline[Type]) fillUnachieved() (bool, error) {
+func (dsc *Keyed[Key, Type]) fillUnachieved() (bool, error) {
 	vacant := dsc.vacantHandlers()
 
 	if vacant == 0 {
@@ -350,20 +1061,21 @@ func (dsc *Discipline[Type]) fillUnachieved() (bool, error) {
 	dsc.prepareUnachieved()
 	dsc.resetTactic()
 
-	if err := divide(dsc.opts.Divider, vacant, dsc.unachieved, dsc.tactic); err != nil {
+	err := divide(dsc.opts.Divider, vacant, dsc.unachieved, dsc.tactic)
+	if err := dsc.divided(err); err != nil {
 		return false, err
 	}
 
 	return distrib.IsFilled(dsc.unachieved, dsc.tactic), nil
 }
 
-func (dsc *Discipline[Type]) vacantHandlers() uint {
+func (dsc *Keyed[Key, Type]) vacantHandlers() uint {
 	// Integer overflow or incorrect counting are not possible here because
 	// the correctness of the distribution is checked at each dividing
 	return dsc.opts.HandlersQuantity - dsc.busyHandlers()
 }
 
-func (dsc *Discipline[Type]) busyHandlers() uint {
+func (dsc *Keyed[Key, Type]) busyHandlers() uint {
 	busy := uint(0)
 
 	// Integer overflow or incorrect counting are not possible here because
@@ -372,10 +1084,14 @@ func (dsc *Discipline[Type]) busyHandlers() uint {
 		busy += dsc.actual[priority]
 	}
 
+	for _, priority := range dsc.retired {
+		busy += dsc.actual[priority]
+	}
+
 	return busy
 }
 
-func (dsc *Discipline[Type]) prepareUnachieved() {
+func (dsc *Keyed[Key, Type]) prepareUnachieved() {
 	dsc.unachieved = dsc.unachieved[:0]
 
 	for _, priority := range dsc.priorities {
@@ -385,13 +1101,13 @@ func (dsc *Discipline[Type]) prepareUnachieved() {
 	}
 }
 
-func (dsc *Discipline[Type]) resetTactic() {
+func (dsc *Keyed[Key, Type]) resetTactic() {
 	for _, priority := range dsc.priorities {
 		dsc.tactic[priority] = 0
 	}
 }
 
-func (dsc *Discipline[Type]) fillOperative() (bool, error) {
+func (dsc *Keyed[Key, Type]) fillOperative() (bool, error) {
 	dsc.prepareUseful()
 
 	if len(dsc.useful) == 0 {
@@ -401,18 +1117,18 @@ func (dsc *Discipline[Type]) fillOperative() (bool, error) {
 	dsc.resetOperative()
 
 	err := divide(dsc.opts.Divider, dsc.opts.HandlersQuantity, dsc.useful, dsc.operative)
-	if err != nil {
+	if err := dsc.divided(err); err != nil {
 		return false, err
 	}
 
 	if !distrib.IsFilled(dsc.useful, dsc.operative) {
-		return false, ErrDividerBad
+		return false, dsc.divided(ErrDividerBad)
 	}
 
 	return true, nil
 }
 
-func (dsc *Discipline[Type]) prepareUseful() {
+func (dsc *Keyed[Key, Type]) prepareUseful() {
 	dsc.useful = dsc.useful[:0]
 
 	// Is used the main priority list because it is necessary to take into account
@@ -425,29 +1141,31 @@ func (dsc *Discipline[Type]) prepareUseful() {
 	}
 }
 
-func (dsc *Discipline[Type]) resetOperative() {
+func (dsc *Keyed[Key, Type]) resetOperative() {
 	for _, priority := range dsc.priorities {
 		dsc.operative[priority] = 0
 	}
 }
 
-func (dsc *Discipline[Type]) waitFillingUnreached() error {
+func (dsc *Keyed[Key, Type]) waitFillingUnreached() (bool, error) {
 	for {
 		filled, err := dsc.fillUnreached()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if filled {
-			return nil
+			return true, nil
 		}
 
-		dsc.waitRelease()
+		if dsc.waitReleaseOrChange() {
+			return false, nil
+		}
 	}
 }
 
 // Fills tactical distribution for unreached priorities.
-func (dsc *Discipline[Type]) fillUnreached() (bool, error) {
+func (dsc *Keyed[Key, Type]) fillUnreached() (bool, error) {
 	vacant := dsc.vacantHandlers()
 
 	if vacant == 0 {
@@ -457,14 +1175,15 @@ func (dsc *Discipline[Type]) fillUnreached() (bool, error) {
 	dsc.prepareUnreached()
 	dsc.resetTactic()
 
-	if err := divide(dsc.opts.Divider, vacant, dsc.unreached, dsc.tactic); err != nil {
+	err := divide(dsc.opts.Divider, vacant, dsc.unreached, dsc.tactic)
+	if err := dsc.divided(err); err != nil {
 		return false, err
 	}
 
 	return distrib.IsFilled(dsc.unreached, dsc.tactic), nil
 }
 
-func (dsc *Discipline[Type]) prepareUnreached() {
+func (dsc *Keyed[Key, Type]) prepareUnreached() {
 	dsc.unreached = dsc.unreached[:0]
 
 	for _, priority := range dsc.useful {
@@ -474,7 +1193,7 @@ func (dsc *Discipline[Type]) prepareUnreached() {
 	}
 }
 
-func (dsc *Discipline[Type]) transfer(priorities []uint) uint {
+func (dsc *Keyed[Key, Type]) transfer(priorities []Key) uint {
 	transferred := uint(0)
 
 	for _, priority := range priorities {
@@ -488,10 +1207,15 @@ func (dsc *Discipline[Type]) transfer(priorities []uint) uint {
 	return transferred
 }
 
-func (dsc *Discipline[Type]) pass(priority uint) uint {
+func (dsc *Keyed[Key, Type]) pass(priority Key) uint {
 	passed := uint(0)
 
 	for dsc.tactic[priority] != 0 {
+		if dsc.inputs[priority].IsHolding {
+			passed += dsc.send(dsc.unhold(priority), priority)
+			continue
+		}
+
 		select {
 		case item, opened := <-dsc.inputs[priority].Channel:
 			if !opened {
@@ -508,7 +1232,7 @@ func (dsc *Discipline[Type]) pass(priority uint) uint {
 	return passed
 }
 
-func (dsc *Discipline[Type]) markInputAsClosed(priority uint) {
+func (dsc *Keyed[Key, Type]) markInputAsClosed(priority Key) {
 	input := dsc.inputs[priority]
 
 	input.Closed = true
@@ -516,16 +1240,98 @@ func (dsc *Discipline[Type]) markInputAsClosed(priority uint) {
 	dsc.inputs[priority] = input
 }
 
-func (dsc *Discipline[Type]) send(item Type, priority uint) uint {
-	prioritized := priodefs.Prioritized[Type]{
+func (dsc *Keyed[Key, Type]) send(item Type, priority Key) uint {
+	prioritized := priodefs.KeyedPrioritized[Key, Type]{
 		Item:     item,
 		Priority: priority,
 	}
 
-	dsc.output <- prioritized
+	dsc.write(prioritized)
 
 	dsc.tactic[priority]--
 	dsc.actual[priority]++
 
 	return 1
 }
+
+// Writes a data item to the output channel.
+//
+// If the quantity of data handlers has been increased beyond the capacity of the
+// output and release channels, then handlers can be blocked at releasing while the
+// output channel is full, so releases are collected while waiting for writing. Also
+// requests for the discipline state are answered because the output channel can be
+// full for a long time.
+func (dsc *Keyed[Key, Type]) write(prioritized priodefs.KeyedPrioritized[Key, Type]) {
+	if dsc.leases != nil {
+		prioritized.Ticket = dsc.leases.issue(prioritized.Priority, time.Now())
+	}
+
+	select {
+	case dsc.output <- prioritized:
+		dsc.dispatched(prioritized.Priority)
+		return
+	default:
+	}
+
+	for {
+		select {
+		case dsc.output <- prioritized:
+			dsc.dispatched(prioritized.Priority)
+			return
+		case priority := <-dsc.release:
+			dsc.released(priority)
+		case request := <-dsc.stats:
+			dsc.answer(request)
+		}
+	}
+}
+
+// Reclaims leases whose processing timeout has expired. Is run in a separate
+// goroutine because the main goroutine of the discipline can be blocked for a long
+// time while writing to the output channel.
+//
+// Terminates together with the discipline. Reclaimed data items are released
+// before the release channel is closed, because the discipline waits for the
+// release of all data items before termination.
+func (dsc *Keyed[Key, Type]) reclaim() {
+	timer := time.NewTimer(dsc.opts.ProcessingTimeout)
+	defer timer.Stop()
+
+	// Observer is notified of the reclamations only if it implements the optional
+	// interface
+	reclaimer, _ := dsc.opts.Observer.(observe.ReclaimObserver[Key])
+
+	var expired []Key
+
+	for {
+		select {
+		case <-dsc.done:
+			return
+		case <-timer.C:
+		}
+
+		var next time.Duration
+
+		expired, next = dsc.leases.reclaim(time.Now(), expired[:0])
+
+		for _, priority := range expired {
+			if reclaimer != nil {
+				reclaimer.OnReclaim(priority)
+			}
+
+			dsc.release <- priority
+		}
+
+		timer.Reset(next)
+	}
+}
+
+// Reports the error of the divider, if any, to the observer and returns it. Too
+// small quantity of data handlers is not considered an error of the divider.
+func (dsc *Keyed[Key, Type]) divided(err error) error {
+	if err != nil && !errors.Is(err, ErrHandlersQuantityTooSmall) {
+		dsc.opts.Observer.OnDividerError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,215 @@
+package priority
+
+import (
+	"testing"
+
+	"github.com/akramarenkov/flow/observe"
+	"github.com/akramarenkov/flow/priority/divider"
+
+	"github.com/stretchr/testify/require"
+)
+
+type class string
+
+const (
+	classBronze class = "bronze"
+	classGold   class = "gold"
+	classSilver class = "silver"
+)
+
+func TestKeyedOptsValidation(t *testing.T) {
+	opts := KeyedOpts[class, uint]{
+		Divider:          divider.KeyedFair[class],
+		HandlersQuantity: 1,
+	}
+
+	require.ErrorIs(t, opts.AddInput("", make(chan uint)), ErrPriorityZero)
+
+	opts.Inputs = map[class]<-chan uint{
+		"": make(chan uint),
+	}
+
+	_, err := NewKeyed(opts)
+	require.ErrorIs(t, err, ErrPriorityZero)
+}
+
+func TestDisciplineKeyed(t *testing.T) {
+	const itemsQuantity = 100
+
+	weighted, err := divider.Weighted(map[class]uint{classGold: 3, classSilver: 2, classBronze: 1})
+	require.NoError(t, err)
+
+	inputs := map[class]chan uint{
+		classBronze: make(chan uint, itemsQuantity),
+		classGold:   make(chan uint, itemsQuantity),
+		classSilver: make(chan uint, itemsQuantity),
+	}
+
+	observer := &keyedCounters{
+		dispatched: make(map[class]uint),
+	}
+
+	opts := KeyedOpts[class, uint]{
+		Divider:          weighted,
+		HandlersQuantity: 6,
+		Observer:         observer,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := NewKeyed(opts)
+	require.NoError(t, err)
+
+	stats := discipline.Stats()
+	require.Equal(t, []class{classSilver, classGold, classBronze}, stats.Priorities)
+	require.Equal(t, map[class]uint{classGold: 3, classSilver: 2, classBronze: 1}, stats.Strategic)
+
+	for _, input := range inputs {
+		for item := range uint(itemsQuantity) {
+			input <- item
+		}
+
+		close(input)
+	}
+
+	for prioritized := range discipline.Output() {
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+
+	for priority := range inputs {
+		require.Equal(t, uint(itemsQuantity), observer.dispatched[priority])
+	}
+}
+
+func TestDisciplineKeyedStrict(t *testing.T) {
+	const itemsQuantity = 10
+
+	inputs := map[class]chan class{
+		classBronze: make(chan class, itemsQuantity),
+		classGold:   make(chan class, itemsQuantity),
+		classSilver: make(chan class, itemsQuantity),
+	}
+
+	opts := KeyedOpts[class, class]{
+		Divider:          divider.KeyedStrict[class],
+		HandlersQuantity: 2,
+		PendingOnly:      true,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	for priority, input := range inputs {
+		for range itemsQuantity {
+			input <- priority
+		}
+
+		close(input)
+	}
+
+	discipline, err := NewKeyed(opts)
+	require.NoError(t, err)
+
+	received := make([]class, 0, len(inputs)*itemsQuantity)
+
+	for prioritized := range discipline.Output() {
+		require.Equal(t, prioritized.Priority, prioritized.Item)
+
+		received = append(received, prioritized.Priority)
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, received, len(inputs)*itemsQuantity)
+
+	// Priorities are ordered by the values of keys
+	require.IsNonIncreasing(t, received)
+}
+
+func TestDisciplineKeyedHierarchy(t *testing.T) {
+	const itemsQuantity = 10
+
+	hierarchy, err := divider.KeyedHierarchy(
+		divider.KeyedGroup[class]{
+			Divider: divider.Fair,
+			Groups: map[uint]divider.KeyedGroup[class]{
+				2: {
+					Divider:    divider.Rate,
+					Priorities: map[uint]class{3: classGold, 1: classSilver},
+				},
+				1: {
+					Divider:    divider.Rate,
+					Priorities: map[uint]class{1: classBronze},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	inputs := map[class]chan class{
+		classBronze: make(chan class, itemsQuantity),
+		classGold:   make(chan class, itemsQuantity),
+		classSilver: make(chan class, itemsQuantity),
+	}
+
+	opts := KeyedOpts[class, class]{
+		Divider:          hierarchy,
+		HandlersQuantity: 8,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	for priority, input := range inputs {
+		for range itemsQuantity {
+			input <- priority
+		}
+
+		close(input)
+	}
+
+	discipline, err := NewKeyed(opts)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		map[class]uint{classBronze: 4, classGold: 3, classSilver: 1},
+		discipline.Stats().Strategic,
+	)
+
+	received := make(map[class]uint, len(inputs))
+
+	for prioritized := range discipline.Output() {
+		require.Equal(t, prioritized.Priority, prioritized.Item)
+
+		received[prioritized.Priority]++
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Equal(
+		t,
+		map[class]uint{
+			classBronze: itemsQuantity,
+			classGold:   itemsQuantity,
+			classSilver: itemsQuantity,
+		},
+		received,
+	)
+}
+
+type keyedCounters struct {
+	observe.PriorityNop[class]
+
+	dispatched map[class]uint
+}
+
+func (kc *keyedCounters) OnDispatch(priority class) {
+	kc.dispatched[priority]++
+}
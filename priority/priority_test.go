@@ -1,10 +1,14 @@
 package priority
 
 import (
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/akramarenkov/flow/clock"
+	"github.com/akramarenkov/flow/observe"
 	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/internal/hook"
 	"github.com/akramarenkov/flow/priority/internal/measuring"
 	"github.com/akramarenkov/flow/priority/internal/research"
 	"github.com/akramarenkov/flow/priority/internal/unmanaged"
@@ -130,6 +134,44 @@ func TestDisciplineRate(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDisciplineHierarchy(t *testing.T) {
+	msr, err := measuring.NewMeasurer(12)
+	require.NoError(t, err)
+
+	for priority := range uint(6) {
+		msr.AddWrite(priority+1, 10000)
+	}
+
+	hierarchy, err := divider.Hierarchy(
+		divider.Group{
+			Divider: divider.Fair,
+			Groups: map[uint]divider.Group{
+				1: {Divider: divider.Rate, Priorities: map[uint]uint{3: 3, 2: 2, 1: 1}},
+				2: {Divider: divider.Rate, Priorities: map[uint]uint{3: 6, 2: 5, 1: 4}},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	opts := Opts[uint]{
+		Divider:          hierarchy,
+		HandlersQuantity: msr.HandlersQuantity(),
+		Inputs:           msr.Inputs(),
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Equal(
+		t,
+		map[uint]uint{6: 3, 5: 2, 4: 1, 3: 3, 2: 2, 1: 1},
+		discipline.Stats().Strategic,
+	)
+
+	_, err = msr.Play(discipline)
+	require.NoError(t, err)
+}
+
 func TestDisciplineFairUnbuffered(t *testing.T) {
 	msr, err := measuring.NewMeasurer(6, 0)
 	require.NoError(t, err)
@@ -419,6 +461,685 @@ func TestDisciplineRateOverQuantity(t *testing.T) {
 	}
 }
 
+func TestDisciplineChangeInputs(t *testing.T) {
+	first := make(chan uint, 1)
+	second := make(chan uint, 1)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 6,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, discipline.AddInput(0, second), ErrPriorityZero)
+	require.ErrorIs(t, discipline.AddInput(2, nil), ErrInputEmpty)
+	require.ErrorIs(t, discipline.AddInput(1, second), ErrInputExists)
+	require.NoError(t, discipline.AddInput(2, second))
+	require.ErrorIs(t, discipline.RemoveInput(3), ErrInputNotFound)
+
+	first <- 1
+
+	received := <-discipline.Output()
+	require.Equal(t, priodefs.Prioritized[uint]{Item: 1, Priority: 1}, received)
+
+	second <- 2
+
+	received = <-discipline.Output()
+	require.Equal(t, priodefs.Prioritized[uint]{Item: 2, Priority: 2}, received)
+
+	discipline.Release(received.Priority)
+
+	// Data item of the removed priority is being processed
+	require.NoError(t, discipline.RemoveInput(1))
+	require.ErrorIs(t, discipline.RemoveInput(2), ErrInputLast)
+
+	// Data items of the removed input channel are not read by the discipline
+	first <- 3
+	second <- 4
+
+	received = <-discipline.Output()
+	require.Equal(t, priodefs.Prioritized[uint]{Item: 4, Priority: 2}, received)
+
+	discipline.Release(received.Priority)
+	discipline.Release(1)
+
+	// Re-adding of the removed priority
+	require.NoError(t, discipline.AddInput(1, first))
+
+	received = <-discipline.Output()
+	require.Equal(t, priodefs.Prioritized[uint]{Item: 3, Priority: 1}, received)
+
+	discipline.Release(received.Priority)
+
+	close(first)
+	close(second)
+
+	require.NoError(t, <-discipline.Err())
+	require.ErrorIs(t, discipline.AddInput(3, make(chan uint)), ErrDisciplineTerminated)
+	require.ErrorIs(t, discipline.RemoveInput(1), ErrDisciplineTerminated)
+}
+
+func TestDisciplineChangeInputsError(t *testing.T) {
+	first := make(chan uint)
+	second := make(chan uint)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+			2: second,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.ErrorIs(
+		t,
+		discipline.AddInput(3, make(chan uint)),
+		ErrHandlersQuantityTooSmall,
+	)
+
+	close(first)
+	close(second)
+
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineChangeInputsDividerError(t *testing.T) {
+	first := make(chan uint)
+	second := make(chan uint)
+
+	wrong := func(quantity uint, priorities []uint, distribution map[uint]uint) error {
+		if len(priorities) == 3 {
+			return ErrDividerBad
+		}
+
+		return divider.Fair(quantity, priorities, distribution)
+	}
+
+	opts := Opts[uint]{
+		Divider:          wrong,
+		HandlersQuantity: 6,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+			2: second,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, discipline.AddInput(3, make(chan uint)), ErrDividerBad)
+
+	close(first)
+	close(second)
+
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineStop(t *testing.T) {
+	const quantity = 5
+
+	input := make(chan uint, quantity)
+
+	for item := range uint(quantity) {
+		input <- item
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 3,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	received := 0
+
+	for range opts.HandlersQuantity {
+		<-discipline.Output()
+
+		received++
+	}
+
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- hook.Stop(discipline)
+	}()
+
+	discipline.Release(1)
+
+	require.NoError(t, <-stopped)
+	require.NoError(t, hook.Stop(discipline))
+
+	// Data items of the first two handlers are still being processed
+	require.ErrorIs(t, discipline.AddInput(2, make(chan uint)), ErrDisciplineTerminated)
+
+	discipline.Release(1)
+	discipline.Release(1)
+
+	// Data item already read by the discipline is written to the output channel
+	for prioritized := range discipline.Output() {
+		received++
+
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.ErrorIs(t, hook.Stop(discipline), ErrDisciplineTerminated)
+	require.ErrorIs(t, hook.Stop(opts), hook.ErrDisciplineUnsupported)
+
+	// Data items remaining in the input channel are not read by the discipline
+	require.Equal(t, quantity, received+len(input))
+	require.GreaterOrEqual(t, len(input), quantity-int(opts.HandlersQuantity)-1)
+}
+
+func TestDisciplineObserver(t *testing.T) {
+	const itemsQuantity = 100
+
+	counters := &observe.Counters{}
+
+	inputs := map[uint]chan uint{
+		1: make(chan uint, itemsQuantity),
+		2: make(chan uint, itemsQuantity),
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 6,
+		Observer:         counters,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		for item := range uint(itemsQuantity) {
+			input <- item
+		}
+
+		close(input)
+	}
+
+	for prioritized := range discipline.Output() {
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+
+	require.Equal(t, []uint{1, 2}, counters.Priorities())
+
+	for priority := range inputs {
+		require.Equal(t, uint64(itemsQuantity), counters.Dispatched(priority))
+		require.Equal(t, uint64(itemsQuantity), counters.Released(priority))
+	}
+
+	require.Equal(t, uint64(0), counters.DividerErrors())
+}
+
+func TestDisciplineObserverDividerError(t *testing.T) {
+	input := make(chan uint, 1)
+
+	counters := &observe.Counters{}
+
+	// Fails when at least one data handler is busy
+	wrong := func(quantity uint, priorities []uint, distribution map[uint]uint) error {
+		if quantity < 6 {
+			return ErrDividerBad
+		}
+
+		return divider.Fair(quantity, priorities, distribution)
+	}
+
+	opts := Opts[uint]{
+		Divider:          wrong,
+		HandlersQuantity: 6,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+		Observer: counters,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	prioritized := <-discipline.Output()
+
+	// Gives the discipline time to call the divider while the data handler is busy
+	time.Sleep(100 * time.Millisecond)
+
+	discipline.Release(prioritized.Priority)
+
+	require.ErrorIs(t, <-discipline.Err(), ErrDividerBad)
+	require.Equal(t, uint64(1), counters.DividerErrors())
+	require.Equal(t, uint64(1), counters.Dispatched(1))
+	require.Equal(t, uint64(1), counters.Released(1))
+}
+
+func TestDisciplineSetHandlersQuantity(t *testing.T) {
+	input := make(chan uint, 10)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, discipline.SetHandlersQuantity(0), ErrHandlersQuantityZero)
+
+	for item := range uint(4) {
+		input <- item
+	}
+
+	require.Equal(t, uint(0), (<-discipline.Output()).Item)
+	require.Equal(t, uint(1), (<-discipline.Output()).Item)
+
+	requireNoOutput(t, discipline)
+
+	require.NoError(t, discipline.SetHandlersQuantity(4))
+
+	require.Equal(t, uint(2), (<-discipline.Output()).Item)
+	require.Equal(t, uint(3), (<-discipline.Output()).Item)
+
+	decreased := make(chan error)
+
+	go func() {
+		decreased <- discipline.SetHandlersQuantity(1)
+	}()
+
+	discipline.Release(1)
+	discipline.Release(1)
+	discipline.Release(1)
+
+	require.NoError(t, <-decreased)
+
+	input <- 4
+	input <- 5
+
+	requireNoOutput(t, discipline)
+
+	discipline.Release(1)
+
+	require.Equal(t, uint(4), (<-discipline.Output()).Item)
+
+	requireNoOutput(t, discipline)
+
+	discipline.Release(1)
+
+	require.Equal(t, uint(5), (<-discipline.Output()).Item)
+
+	close(input)
+
+	discipline.Release(1)
+
+	require.NoError(t, <-discipline.Err())
+	require.ErrorIs(t, discipline.SetHandlersQuantity(1), ErrDisciplineTerminated)
+}
+
+func requireNoOutput[Type any](t *testing.T, discipline *Discipline[Type]) {
+	t.Helper()
+
+	select {
+	case <-discipline.Output():
+		require.FailNow(t, "data item was distributed to excess data handler")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDisciplineSetHandlersQuantityError(t *testing.T) {
+	first := make(chan uint)
+	second := make(chan uint)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+			2: second,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, discipline.SetHandlersQuantity(1), ErrHandlersQuantityTooSmall)
+
+	close(first)
+	close(second)
+
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineSetHandlersQuantityOverCapacity(t *testing.T) {
+	const itemsQuantity = 100
+
+	input := make(chan uint, 1)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan uint{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.NoError(t, discipline.SetHandlersQuantity(10))
+
+	received := make(chan uint, itemsQuantity)
+
+	for range 10 {
+		go func() {
+			for prioritized := range discipline.Output() {
+				received <- prioritized.Item
+				discipline.Release(prioritized.Priority)
+			}
+		}()
+	}
+
+	for item := range uint(itemsQuantity) {
+		input <- item
+	}
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, received, itemsQuantity)
+}
+
+func TestDisciplineSparse(t *testing.T) {
+	const (
+		itemsQuantity = 100
+		writingDelay  = 100 * time.Microsecond
+	)
+
+	inputs := map[uint]chan uint{
+		1: make(chan uint),
+		2: make(chan uint),
+		3: make(chan uint),
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 3,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		go func() {
+			defer close(input)
+
+			for item := range uint(itemsQuantity) {
+				time.Sleep(writingDelay)
+
+				input <- item
+			}
+		}()
+	}
+
+	received := make(map[uint][]uint, len(inputs))
+
+	for prioritized := range discipline.Output() {
+		received[prioritized.Priority] = append(
+			received[prioritized.Priority],
+			prioritized.Item,
+		)
+
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+
+	for priority := range inputs {
+		require.Len(t, received[priority], itemsQuantity)
+		require.IsNonDecreasing(t, received[priority])
+	}
+}
+
+func TestDisciplineBoundedUnboundedEmpty(t *testing.T) {
+	testDisciplineBoundedUnboundedIdle(t, false)
+}
+
+func TestDisciplineBoundedUnboundedClosed(t *testing.T) {
+	testDisciplineBoundedUnboundedIdle(t, true)
+}
+
+func testDisciplineBoundedUnboundedIdle(t *testing.T, closed bool) {
+	const itemsQuantity = 100
+
+	bounded, err := divider.Bounded(
+		divider.Rate,
+		10,
+		map[uint]uint{1: 0},
+		map[uint]uint{3: 4},
+	)
+	require.NoError(t, err)
+
+	high := make(chan uint, itemsQuantity)
+	low := make(chan uint)
+
+	for item := range uint(itemsQuantity) {
+		high <- item
+	}
+
+	close(high)
+
+	opts := Opts[uint]{
+		Divider:          bounded,
+		HandlersQuantity: 10,
+		Inputs: map[uint]<-chan uint{
+			1: low,
+			3: high,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	if closed {
+		close(low)
+	}
+
+	received := make([]uint, 0, itemsQuantity)
+
+	for prioritized := range discipline.Output() {
+		require.Equal(t, uint(3), prioritized.Priority)
+
+		received = append(received, prioritized.Item)
+
+		discipline.Release(prioritized.Priority)
+
+		// Only the input channel of the unbounded priority remains open
+		if len(received) == itemsQuantity && !closed {
+			close(low)
+		}
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, received, itemsQuantity)
+	require.IsIncreasing(t, received)
+}
+
+func TestDisciplineStrict(t *testing.T) {
+	const itemsQuantity = 10
+
+	inputs := map[uint]chan uint{
+		1: make(chan uint, itemsQuantity),
+		2: make(chan uint, itemsQuantity),
+		3: make(chan uint, itemsQuantity),
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Strict,
+		HandlersQuantity: 2,
+		PendingOnly:      true,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	_, err := New(Opts[uint]{
+		Divider:          opts.Divider,
+		HandlersQuantity: opts.HandlersQuantity,
+		Inputs:           opts.Inputs,
+	})
+	require.ErrorIs(t, err, ErrHandlersQuantityTooSmall)
+
+	// All data items are pending before the discipline is started
+	for priority, input := range inputs {
+		for item := range uint(itemsQuantity) {
+			input <- priority*itemsQuantity + item
+		}
+
+		close(input)
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	received := make([]uint, 0, len(inputs)*itemsQuantity)
+
+	for prioritized := range discipline.Output() {
+		received = append(received, prioritized.Priority)
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, received, len(inputs)*itemsQuantity)
+	require.IsNonIncreasing(t, received)
+}
+
+func TestDisciplineAging(t *testing.T) {
+	const (
+		itemsQuantity = 10
+		patience      = time.Second
+	)
+
+	clk := clock.NewManual(time.Now())
+
+	inputs := map[uint]chan uint{
+		1: make(chan uint, itemsQuantity),
+		3: make(chan uint, itemsQuantity),
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Aging(divider.Rate, patience, clk),
+		HandlersQuantity: 1,
+		PendingOnly:      true,
+	}
+
+	// All data items are pending before the discipline is started
+	for priority, input := range inputs {
+		for range itemsQuantity {
+			input <- priority
+		}
+
+		close(input)
+
+		require.NoError(t, opts.AddInput(priority, input))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	received := make([]uint, 0, len(inputs)*itemsQuantity)
+
+	for prioritized := range discipline.Output() {
+		received = append(received, prioritized.Priority)
+
+		clk.Advance(patience)
+
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, received, len(inputs)*itemsQuantity)
+
+	// Low priority is served while the high priority still has pending data items
+	require.Less(t, slices.Index(received, 1), itemsQuantity)
+}
+
+func TestDisciplineStrictUnbuffered(t *testing.T) {
+	const itemsQuantity = 100
+
+	inputs := map[uint]chan uint{
+		1: make(chan uint),
+		2: make(chan uint),
+	}
+
+	opts := Opts[uint]{
+		Divider:          divider.Strict,
+		HandlersQuantity: 1,
+		PendingOnly:      true,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		go func() {
+			defer close(input)
+
+			for item := range uint(itemsQuantity) {
+				input <- item
+			}
+		}()
+	}
+
+	received := make(map[uint][]uint, len(inputs))
+
+	for prioritized := range discipline.Output() {
+		received[prioritized.Priority] = append(
+			received[prioritized.Priority],
+			prioritized.Item,
+		)
+
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+
+	for priority := range inputs {
+		require.Len(t, received[priority], itemsQuantity)
+		require.IsIncreasing(t, received[priority])
+	}
+}
+
 func BenchmarkDisciplineFair6(b *testing.B) {
 	benchmarkDiscipline(b, divider.Fair, 6)
 }
@@ -515,6 +1236,30 @@ func BenchmarkDisciplineFair60InputCapacity400(b *testing.B) {
 	benchmarkDiscipline(b, divider.Fair, 60, 240)
 }
 
+func BenchmarkDisciplineFair6Sparse(b *testing.B) {
+	benchmarkDisciplineSparse(b, divider.Fair, 6)
+}
+
+func BenchmarkDisciplineRate6Sparse(b *testing.B) {
+	benchmarkDisciplineSparse(b, divider.Rate, 6)
+}
+
+func BenchmarkUnmanaged6Sparse(b *testing.B) {
+	benchmarkUnmanagedSparse(b, 6)
+}
+
+func BenchmarkDisciplineFair60Sparse(b *testing.B) {
+	benchmarkDisciplineSparse(b, divider.Fair, 60)
+}
+
+func BenchmarkDisciplineRate60Sparse(b *testing.B) {
+	benchmarkDisciplineSparse(b, divider.Rate, 60)
+}
+
+func BenchmarkUnmanaged60Sparse(b *testing.B) {
+	benchmarkUnmanagedSparse(b, 60)
+}
+
 func benchmarkDiscipline(
 	b *testing.B,
 	divisor priodefs.Divider,
@@ -545,6 +1290,58 @@ func benchmarkDiscipline(
 	bnch.Play(discipline)
 }
 
+// Data items are written to the unbuffered input channel of only one priority, while
+// the input channels of other priorities remain empty, so the discipline often waits
+// for data items.
+func benchmarkDisciplineSparse(b *testing.B, divisor priodefs.Divider, handlersQuantity uint) {
+	itemsQuantity, err := safe.IToI[uint](b.N)
+	require.NoError(b, err)
+
+	bnch, err := measuring.NewBenchmarker(handlersQuantity, 0)
+	require.NoError(b, err)
+
+	bnch.AddItems(3, 0)
+	bnch.AddItems(2, 0)
+	bnch.AddItems(1, itemsQuantity)
+
+	opts := Opts[uint]{
+		Divider:          divisor,
+		HandlersQuantity: bnch.HandlersQuantity(),
+		Inputs:           bnch.Inputs(),
+	}
+
+	discipline, err := New(opts)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	bnch.Play(discipline)
+}
+
+func benchmarkUnmanagedSparse(b *testing.B, handlersQuantity uint) {
+	itemsQuantity, err := safe.IToI[uint](b.N)
+	require.NoError(b, err)
+
+	bnch, err := measuring.NewBenchmarker(handlersQuantity, 0)
+	require.NoError(b, err)
+
+	bnch.AddItems(3, 0)
+	bnch.AddItems(2, 0)
+	bnch.AddItems(1, itemsQuantity)
+
+	opts := unmanaged.Opts[uint]{
+		HandlersQuantity: bnch.HandlersQuantity(),
+		Inputs:           bnch.Inputs(),
+	}
+
+	discipline, err := unmanaged.New(opts)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	bnch.Play(discipline)
+}
+
 func benchmarkUnmanaged(
 	b *testing.B,
 	handlersQuantity uint,
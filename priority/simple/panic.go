@@ -0,0 +1,43 @@
+package simple
+
+import "fmt"
+
+// Determines the behavior of the discipline when a handle function panics.
+type PanicPolicy int
+
+const (
+	// Discipline stops receiving data items from the input channels, skips data
+	// items that have already been received and terminates with the panic error
+	// returned from the [Discipline.Err] channel
+	PanicTerminate PanicPolicy = iota
+
+	// Discipline continues processing of data items. Panic errors are written to
+	// the Panics channel of the options if it is specified
+	PanicContinue
+)
+
+// Error describing a panic occurred in a handle function.
+type PanicError struct {
+	// Priority of the data item whose processing caused the panic
+	Priority uint
+
+	// Stack trace of the goroutine at the moment of the panic
+	Stack []byte
+
+	// Value passed to the panic
+	Value any
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrHandlePanicked, pe.Value)
+}
+
+// Returns the [ErrHandlePanicked] error and the value passed to the panic if it is
+// an error.
+func (pe *PanicError) Unwrap() []error {
+	if err, is := pe.Value.(error); is {
+		return []error{ErrHandlePanicked, err}
+	}
+
+	return []error{ErrHandlePanicked}
+}
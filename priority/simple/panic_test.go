@@ -0,0 +1,118 @@
+package simple
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/priodefs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisciplinePanicTerminate(t *testing.T) {
+	// Input channel is not closed, the discipline terminates due to the panic
+	input := make(chan int, 10)
+
+	handle := func(prioritized priodefs.Prioritized[int]) {
+		if prioritized.Item == 5 {
+			panic("unexpected item")
+		}
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range 10 {
+		input <- item
+	}
+
+	err = <-discipline.Err()
+	require.ErrorIs(t, err, ErrHandlePanicked)
+
+	var panicked *PanicError
+
+	require.ErrorAs(t, err, &panicked)
+	require.Equal(t, "unexpected item", panicked.Value)
+	require.Equal(t, uint(1), panicked.Priority)
+	require.Contains(t, string(panicked.Stack), "TestDisciplinePanicTerminate")
+}
+
+func TestDisciplinePanicTerminateHandleErr(t *testing.T) {
+	input := make(chan int, 1)
+
+	handle := func(priodefs.Prioritized[int]) error {
+		panic(errProcessing)
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+		Retry: Retry{
+			Attempts: 3,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	err = <-discipline.Err()
+	require.ErrorIs(t, err, ErrHandlePanicked)
+	require.ErrorIs(t, err, errProcessing)
+}
+
+func TestDisciplinePanicContinue(t *testing.T) {
+	const itemsQuantity = 100
+
+	input := make(chan int, itemsQuantity)
+	panics := make(chan *PanicError, itemsQuantity)
+	processed := make(chan int, itemsQuantity)
+
+	handle := func(prioritized priodefs.Prioritized[int]) {
+		if prioritized.Item%10 == 0 {
+			panic(errors.New("unexpected item"))
+		}
+
+		processed <- prioritized.Item
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+		PanicPolicy: PanicContinue,
+		Panics:      panics,
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range itemsQuantity {
+		input <- item
+	}
+
+	close(input)
+
+	// Handlers are not leaked, so the discipline terminates in normal mode
+	require.NoError(t, <-discipline.Err())
+	require.Len(t, panics, itemsQuantity/10)
+	require.Len(t, processed, itemsQuantity-itemsQuantity/10)
+}
@@ -0,0 +1,212 @@
+package simple
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/akramarenkov/flow/priority/priodefs"
+)
+
+// Retry policy of data items whose processing has failed.
+type Retry struct {
+	// Maximum quantity of attempts to process a data item including the first one.
+	// Zero and one mean that data items are not retried
+	Attempts uint
+
+	// Delay before the first retry. Each next delay is doubled
+	Backoff time.Duration
+
+	// Maximum delay before retry. If not specified, delays are not limited
+	MaxBackoff time.Duration
+}
+
+// Returns the delay before the retry that follows the specified quantity of failed
+// attempts.
+func (retry Retry) delay(failed uint) time.Duration {
+	delay := max(retry.Backoff, 0)
+
+	for range failed - 1 {
+		if retry.MaxBackoff > 0 && delay >= retry.MaxBackoff {
+			break
+		}
+
+		// Protection against integer overflow
+		if delay > math.MaxInt64/2 {
+			return math.MaxInt64
+		}
+
+		delay *= 2
+	}
+
+	if retry.MaxBackoff > 0 {
+		return min(delay, retry.MaxBackoff)
+	}
+
+	return delay
+}
+
+// Data item that has exhausted its retries.
+type Failure[Type any] struct {
+	// Quantity of attempts made to process the data item
+	Attempts uint
+
+	// Error returned by the last attempt
+	Err error
+
+	// Data item and its priority
+	Prioritized priodefs.Prioritized[Type]
+}
+
+// Data item passed through the underlying priority discipline.
+type entry[Type any] struct {
+	// Quantity of failed attempts to process the data item
+	failed uint
+	item   Type
+}
+
+// Data item waiting for the delay before retry.
+type delayed[Type any] struct {
+	retried entry[Type]
+	timer   *time.Timer
+}
+
+// Queue of data items of one priority that are being retried.
+type queue[Type any] struct {
+	mutex sync.Mutex
+	// Quantity of data items passed to the underlying priority discipline, but not
+	// yet processed successfully or finally failed
+	inflight uint
+	retries  []entry[Type]
+	// Data items waiting for the delay before retry
+	delayed map[uint64]delayed[Type]
+	lastID  uint64
+	// Indicates that the queue is drained and does not accept data items anymore
+	drained bool
+
+	// Wakes up the forwarder when the queue is changed
+	notify chan struct{}
+}
+
+func newQueue[Type any]() *queue[Type] {
+	qu := &queue[Type]{
+		delayed: make(map[uint64]delayed[Type]),
+		notify:  make(chan struct{}, 1),
+	}
+
+	return qu
+}
+
+func (qu *queue[Type]) acquire() {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	qu.inflight++
+}
+
+// Completes processing of a data item.
+func (qu *queue[Type]) complete() {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	qu.inflight--
+
+	qu.wake()
+}
+
+// Puts a data item to the queue for retry after the specified delay. Data item
+// remains in flight. Returns false if the queue is drained.
+func (qu *queue[Type]) delay(retried entry[Type], delay time.Duration) bool {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	if qu.drained {
+		return false
+	}
+
+	// Integer overflow is practically impossible because even when retrying a
+	// billion data items per second the identifiers will last for hundreds of years
+	qu.lastID++
+
+	id := qu.lastID
+
+	qu.delayed[id] = delayed[Type]{
+		retried: retried,
+		timer:   time.AfterFunc(delay, func() { qu.resume(id) }),
+	}
+
+	return true
+}
+
+func (qu *queue[Type]) resume(id uint64) {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	waiting, exists := qu.delayed[id]
+	if !exists {
+		return
+	}
+
+	delete(qu.delayed, id)
+
+	qu.retries = append(qu.retries, waiting.retried)
+
+	qu.wake()
+}
+
+// Removes all data items from the queue, including those waiting for the delay,
+// and stops accepting new ones.
+func (qu *queue[Type]) drain() []Type {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	qu.drained = true
+
+	items := make([]Type, 0, len(qu.retries)+len(qu.delayed))
+
+	for _, retried := range qu.retries {
+		items = append(items, retried.item)
+	}
+
+	for _, waiting := range qu.delayed {
+		waiting.timer.Stop()
+
+		items = append(items, waiting.retried.item)
+	}
+
+	qu.retries = nil
+	clear(qu.delayed)
+
+	return items
+}
+
+func (qu *queue[Type]) pop() (entry[Type], bool) {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	if len(qu.retries) == 0 {
+		return entry[Type]{}, false
+	}
+
+	retried := qu.retries[0]
+
+	// Protection against holding references to data items
+	qu.retries[0] = entry[Type]{}
+	qu.retries = qu.retries[1:]
+
+	return retried, true
+}
+
+func (qu *queue[Type]) isIdle() bool {
+	qu.mutex.Lock()
+	defer qu.mutex.Unlock()
+
+	return qu.inflight == 0 && len(qu.retries) == 0
+}
+
+func (qu *queue[Type]) wake() {
+	select {
+	case qu.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,241 @@
+package simple
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/priodefs"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errProcessing = errors.New("processing failed")
+
+func TestRetryDelay(t *testing.T) {
+	retry := Retry{
+		Backoff:    time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+
+	require.Equal(t, time.Second, retry.delay(1))
+	require.Equal(t, 2*time.Second, retry.delay(2))
+	require.Equal(t, 4*time.Second, retry.delay(3))
+	require.Equal(t, 5*time.Second, retry.delay(4))
+	require.Equal(t, 5*time.Second, retry.delay(100))
+
+	retry.MaxBackoff = 0
+
+	require.Equal(t, 8*time.Second, retry.delay(4))
+	require.Equal(t, time.Duration(math.MaxInt64), retry.delay(100))
+
+	require.Zero(t, Retry{}.delay(1))
+	require.Zero(t, Retry{Backoff: -time.Second}.delay(2))
+}
+
+func TestOptsValidationHandleErr(t *testing.T) {
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           func(priodefs.Prioritized[int]) {},
+		HandleErr:        func(priodefs.Prioritized[int]) error { return nil },
+		HandlersQuantity: 6,
+		Inputs: map[uint]<-chan int{
+			1: make(chan int),
+		},
+	}
+
+	_, err := New(opts)
+	require.ErrorIs(t, err, ErrHandleAmbiguous)
+
+	opts.Handle = nil
+
+	_, err = New(opts)
+	require.NoError(t, err)
+
+	opts.Inputs[2] = nil
+
+	_, err = New(opts)
+	require.Error(t, err)
+}
+
+func TestDisciplineHandleErr(t *testing.T) {
+	const (
+		failures      = 2
+		itemsQuantity = 100
+	)
+
+	inputs := map[uint]chan int{
+		2: make(chan int, itemsQuantity),
+		1: make(chan int, itemsQuantity),
+	}
+
+	mutex := &sync.Mutex{}
+	attempts := make(map[uint]map[int]int)
+
+	handle := func(prioritized priodefs.Prioritized[int]) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if attempts[prioritized.Priority] == nil {
+			attempts[prioritized.Priority] = make(map[int]int)
+		}
+
+		attempts[prioritized.Priority][prioritized.Item]++
+
+		if attempts[prioritized.Priority][prioritized.Item] <= failures {
+			return errProcessing
+		}
+
+		return nil
+	}
+
+	deadLetter := make(chan Failure[int], 1)
+
+	opts := Opts[int]{
+		DeadLetter:       deadLetter,
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 6,
+		Retry: Retry{
+			Attempts: failures + 1,
+			Backoff:  time.Millisecond,
+		},
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		for item := range itemsQuantity {
+			input <- item
+		}
+
+		// Discipline is not terminated while there are retried data items
+		close(input)
+	}
+
+	require.NoError(t, <-discipline.Err())
+	require.Empty(t, deadLetter)
+
+	for priority := range inputs {
+		require.Len(t, attempts[priority], itemsQuantity)
+
+		for item := range itemsQuantity {
+			require.Equal(t, failures+1, attempts[priority][item])
+		}
+	}
+}
+
+func TestDisciplineHandleErrDeadLetter(t *testing.T) {
+	const itemsQuantity = 10
+
+	input := make(chan int, itemsQuantity)
+	deadLetter := make(chan Failure[int], itemsQuantity)
+
+	handle := func(prioritized priodefs.Prioritized[int]) error {
+		if prioritized.Item%2 == 0 {
+			return errProcessing
+		}
+
+		return nil
+	}
+
+	opts := Opts[int]{
+		DeadLetter:       deadLetter,
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+		Retry: Retry{
+			Attempts: 3,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range itemsQuantity {
+		input <- item
+	}
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+
+	close(deadLetter)
+
+	failed := make([]int, 0, itemsQuantity)
+
+	for failure := range deadLetter {
+		require.Equal(t, uint(3), failure.Attempts)
+		require.ErrorIs(t, failure.Err, errProcessing)
+		require.Equal(t, uint(1), failure.Prioritized.Priority)
+
+		failed = append(failed, failure.Prioritized.Item)
+	}
+
+	require.ElementsMatch(t, []int{0, 2, 4, 6, 8}, failed)
+}
+
+func TestDisciplineHandleErrNotBlocking(t *testing.T) {
+	const backoff = 500 * time.Millisecond
+
+	input := make(chan int, 2)
+	processed := make(chan int, 2)
+
+	failed := false
+
+	// Called only from the single handler
+	handle := func(prioritized priodefs.Prioritized[int]) error {
+		if prioritized.Item == 1 && !failed {
+			failed = true
+			return errProcessing
+		}
+
+		processed <- prioritized.Item
+
+		return nil
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+		Retry: Retry{
+			Attempts: 2,
+			Backoff:  backoff,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	startedAt := time.Now()
+
+	input <- 1
+	input <- 2
+
+	close(input)
+
+	// Second data item is processed by the only handler while the first one is
+	// waiting for retry
+	require.Equal(t, 2, <-processed)
+	require.Less(t, time.Since(startedAt), backoff)
+
+	require.Equal(t, 1, <-processed)
+	require.GreaterOrEqual(t, time.Since(startedAt), backoff)
+
+	require.NoError(t, <-discipline.Err())
+}
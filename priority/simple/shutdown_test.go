@@ -0,0 +1,305 @@
+package simple
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/priority/divider"
+	"github.com/akramarenkov/flow/priority/priodefs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisciplineWait(t *testing.T) {
+	const itemsQuantity = 100
+
+	input := make(chan int, itemsQuantity)
+
+	var processed atomic.Int64
+
+	handle := func(priodefs.Prioritized[int]) {
+		time.Sleep(time.Millisecond)
+		processed.Add(1)
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 6,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range itemsQuantity {
+		input <- item
+	}
+
+	close(input)
+
+	require.NoError(t, discipline.Wait())
+	require.Equal(t, int64(itemsQuantity), processed.Load())
+	require.NoError(t, discipline.Wait())
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineShutdown(t *testing.T) {
+	const itemsQuantity = 100
+
+	inputs := map[uint]chan int{
+		2: make(chan int, itemsQuantity),
+		1: make(chan int, itemsQuantity),
+	}
+
+	var (
+		running   atomic.Int64
+		processed atomic.Int64
+	)
+
+	started := make(chan struct{}, 1)
+
+	handle := func(priodefs.Prioritized[int]) {
+		running.Add(1)
+		defer running.Add(-1)
+
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		processed.Add(1)
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 2,
+	}
+
+	for priority, channel := range inputs {
+		require.NoError(t, opts.AddInput(priority, channel))
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		for item := range itemsQuantity {
+			input <- item
+		}
+	}
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+
+	undispatched, err := discipline.Shutdown(ctx)
+	require.NoError(t, err)
+	require.Zero(t, running.Load())
+
+	// Every data item is either processed or returned
+	total := processed.Load()
+
+	for priority, input := range inputs {
+		require.Zero(t, len(input))
+
+		total += int64(len(undispatched[priority]))
+	}
+
+	require.Equal(t, int64(len(inputs)*itemsQuantity), total)
+	require.Less(t, processed.Load(), int64(len(inputs)*itemsQuantity))
+
+	require.NoError(t, discipline.Wait())
+}
+
+func TestDisciplineShutdownForwarded(t *testing.T) {
+	const itemsQuantity = 2
+
+	input := make(chan int)
+	blocker := make(chan struct{})
+	started := make(chan struct{}, itemsQuantity)
+
+	handled := make([]int, 0, itemsQuantity)
+
+	handle := func(prioritized priodefs.Prioritized[int]) error {
+		handled = append(handled, prioritized.Item)
+
+		started <- struct{}{}
+		<-blocker
+
+		return nil
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 0
+
+	<-started
+
+	// While the only handler is busy, the data item is held by the forwarder
+	// because the underlying priority discipline does not receive it
+	input <- 1
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err = discipline.Shutdown(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(blocker)
+
+	undispatched, err := discipline.Shutdown(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []int{0}, handled)
+	require.Equal(t, map[uint][]int{1: {1}}, undispatched)
+}
+
+func TestDisciplineShutdownBuffered(t *testing.T) {
+	const itemsQuantity = 10
+
+	input := make(chan int, itemsQuantity)
+	blocker := make(chan struct{})
+	started := make(chan struct{}, itemsQuantity)
+
+	handled := make([]int, 0, itemsQuantity)
+
+	handle := func(prioritized priodefs.Prioritized[int]) {
+		handled = append(handled, prioritized.Item)
+
+		started <- struct{}{}
+		<-blocker
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 0
+
+	<-started
+
+	for item := 1; item < itemsQuantity; item++ {
+		input <- item
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err = discipline.Shutdown(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(blocker)
+
+	// Data items buffered in the input channel are returned too, although the input
+	// channel is not closed
+	undispatched, err := discipline.Shutdown(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []int{0}, handled)
+	require.Zero(t, len(input))
+	require.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, undispatched[1])
+}
+
+func TestDisciplineShutdownContextDone(t *testing.T) {
+	input := make(chan int, 1)
+	blocker := make(chan struct{})
+	started := make(chan struct{})
+
+	handle := func(priodefs.Prioritized[int]) {
+		close(started)
+		<-blocker
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = discipline.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(blocker)
+
+	require.NoError(t, discipline.Wait())
+}
+
+func TestDisciplineShutdownRetried(t *testing.T) {
+	const itemsQuantity = 3
+
+	input := make(chan int, itemsQuantity)
+	failed := make(chan struct{}, itemsQuantity)
+
+	handle := func(priodefs.Prioritized[int]) error {
+		failed <- struct{}{}
+		return errProcessing
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		HandleErr:        handle,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+		Retry: Retry{
+			Attempts: 2,
+			Backoff:  time.Hour,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range itemsQuantity {
+		input <- item
+	}
+
+	close(input)
+
+	for range itemsQuantity {
+		<-failed
+	}
+
+	// Data items waiting for the retry are returned without waiting for the delay
+	undispatched, err := discipline.Shutdown(t.Context())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{0, 1, 2}, undispatched[1])
+}
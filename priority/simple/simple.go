@@ -2,19 +2,32 @@
 package simple
 
 import (
+	"context"
 	"errors"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
 
+	"github.com/akramarenkov/flow/observe"
 	priocore "github.com/akramarenkov/flow/priority"
+	"github.com/akramarenkov/flow/priority/internal/hook"
 	"github.com/akramarenkov/flow/priority/priodefs"
 )
 
 var (
-	ErrHandleEmpty = errors.New("handle function was not specified")
+	ErrHandleAmbiguous = errors.New("both handle functions were specified")
+	ErrHandleEmpty     = errors.New("handle function was not specified")
+	ErrHandlePanicked  = errors.New("handle function panicked")
 )
 
 // Callback function called in handlers when an data item is received.
 type Handle[P priodefs.Prioritized[Type], Type any] func(prioritized P)
 
+// Callback function called in handlers when an data item is received, which can
+// fail processing of the data item.
+type HandleErr[P priodefs.Prioritized[Type], Type any] func(prioritized P) error
+
 // Options of the created discipline.
 type Opts[Type any] struct {
 	// Determines in what quantity data items distributed among data handlers
@@ -23,10 +36,31 @@ type Opts[Type any] struct {
 	// divider or custom divider
 	Divider priodefs.Divider
 
+	// Output channel of data items that have exhausted their retries. Is used only
+	// with the HandleErr function. If specified, it must be read, otherwise the
+	// handlers will be blocked. Is not closed by the discipline. If not specified,
+	// such data items are dropped
+	DeadLetter chan<- Failure[Type]
+
 	// Callback function called in handlers when an data item is received
+	//
+	// Only one of the Handle and HandleErr functions must be specified
 	Handle Handle[priodefs.Prioritized[Type], Type]
 
+	// Callback function called in handlers when an data item is received, which can
+	// fail processing of the data item by returning an error
+	//
+	// Failed data items are retried in accordance with the Retry policy. Retried
+	// data item is put back to the queue of its priority after the delay, so the
+	// handler is released immediately and the data item competes for handlers again
+	// with the other data items. The discipline is not terminated until all retried
+	// data items are processed or exhausted their retries
+	HandleErr HandleErr[priodefs.Prioritized[Type], Type]
+
 	// Quantity of data handlers between which data items are distributed
+	//
+	// Can be changed while the discipline is running by the
+	// [Discipline.SetHandlersQuantity] method
 	HandlersQuantity uint
 
 	// Input channels of data items. For terminate the discipline it is necessary and
@@ -36,6 +70,25 @@ type Opts[Type any] struct {
 	//
 	// Map key is a value of priority. Zero priority is not allowed
 	Inputs map[uint]<-chan Type
+
+	// Observer of the internal events of the discipline. Is passed to the underlying
+	// priority discipline, see its options for details
+	Observer observe.Observer
+
+	// Determines whether the discipline continues or terminates when a handle
+	// function panics. In any case, the panic is recovered and the data item is
+	// released. Data items whose processing caused a panic are not retried. By
+	// default, the discipline terminates
+	PanicPolicy PanicPolicy
+
+	// Output channel of panic errors used with the PanicContinue policy. If
+	// specified, it must be read, otherwise the handlers will be blocked. Is not
+	// closed by the discipline. If not specified, panics are not reported
+	Panics chan<- *PanicError
+
+	// Retry policy of data items whose processing by the HandleErr function has
+	// failed. By default, failed data items are not retried
+	Retry Retry
 }
 
 // Adds an input channel with the specified priority to the inputs map.
@@ -62,18 +115,64 @@ func (opts *Opts[Type]) AddInput(priority uint, channel <-chan Type) error {
 }
 
 func (opts Opts[Type]) isValid() error {
-	if opts.Handle == nil {
+	if opts.Handle == nil && opts.HandleErr == nil {
 		return ErrHandleEmpty
 	}
 
+	if opts.Handle != nil && opts.HandleErr != nil {
+		return ErrHandleAmbiguous
+	}
+
 	return nil
 }
 
+// Methods of the underlying priority discipline that do not depend on the type of
+// its data items.
+type controller interface {
+	Err() <-chan error
+	SetHandlersQuantity(quantity uint) error
+}
+
 // Simplified priority discipline.
 type Discipline[Type any] struct {
 	opts Opts[Type]
 
-	core *priocore.Discipline[Type]
+	core controller
+	// Underlying priority discipline that receives data items directly from the
+	// input channels. Is used if the HandleErr function is not specified
+	plain *priocore.Discipline[Type]
+	// Underlying priority discipline that receives data items through the
+	// forwarders. Is used if the HandleErr function is specified
+	retrying *priocore.Discipline[entry[Type]]
+	// Queues of retried data items by priorities
+	queues map[uint]*queue[Type]
+
+	// Indicates that the underlying priority discipline has terminated, so the
+	// handlers are not started anymore
+	finished bool
+	// Quantity of running handlers
+	handlers   uint
+	mutex      sync.Mutex
+	retire     chan struct{}
+	terminated chan struct{}
+	terminator sync.Once
+
+	// Panic that caused the termination of the discipline
+	panicked atomic.Pointer[PanicError]
+	stop     chan struct{}
+	stopper  sync.Once
+
+	// Data items received from the input channels, but not passed to the handle
+	// function due to the termination
+	undispatched      map[uint][]Type
+	undispatchedMutex sync.Mutex
+
+	// Running handlers, forwarders, halter and underlying priority discipline
+	wg sync.WaitGroup
+
+	done   chan struct{}
+	err    chan error
+	result error
 }
 
 // Creates and runs discipline.
@@ -82,21 +181,84 @@ func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
 		return nil, err
 	}
 
+	if opts.HandleErr == nil {
+		return newPlain(opts)
+	}
+
+	return newRetrying(opts)
+}
+
+func newPlain[Type any](opts Opts[Type]) (*Discipline[Type], error) {
 	core, err := priocore.New(
 		priocore.Opts[Type]{
 			Divider:          opts.Divider,
 			HandlersQuantity: opts.HandlersQuantity,
 			Inputs:           opts.Inputs,
+			Observer:         opts.Observer,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	dsc := &Discipline[Type]{
-		opts: opts,
+	dsc := newDiscipline(opts)
+
+	dsc.core = core
+	dsc.plain = core
+
+	dsc.wg.Add(1)
+
+	go dsc.halt()
+
+	dsc.main()
 
-		core: core,
+	return dsc, nil
+}
+
+func newRetrying[Type any](opts Opts[Type]) (*Discipline[Type], error) {
+	// Data items are passed to the underlying priority discipline through
+	// intermediate channels to be able to put retried data items back
+	inputs := make(map[uint]<-chan entry[Type], len(opts.Inputs))
+	forwarded := make(map[uint]chan entry[Type], len(opts.Inputs))
+
+	for priority, input := range opts.Inputs {
+		// Validation of input channels is performed by the underlying priority
+		// discipline
+		if input == nil {
+			inputs[priority] = nil
+			continue
+		}
+
+		forwarded[priority] = make(chan entry[Type], cap(input))
+		inputs[priority] = forwarded[priority]
+	}
+
+	core, err := priocore.New(
+		priocore.Opts[entry[Type]]{
+			Divider:          opts.Divider,
+			HandlersQuantity: opts.HandlersQuantity,
+			Inputs:           inputs,
+			Observer:         opts.Observer,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dsc := newDiscipline(opts)
+
+	dsc.core = core
+	dsc.retrying = core
+	dsc.queues = make(map[uint]*queue[Type], len(forwarded))
+
+	for priority := range forwarded {
+		dsc.queues[priority] = newQueue[Type]()
+	}
+
+	for priority, output := range forwarded {
+		dsc.wg.Add(1)
+
+		go dsc.forward(priority, opts.Inputs[priority], output)
 	}
 
 	dsc.main()
@@ -104,29 +266,440 @@ func New[Type any](opts Opts[Type]) (*Discipline[Type], error) {
 	return dsc, nil
 }
 
+func newDiscipline[Type any](opts Opts[Type]) *Discipline[Type] {
+	dsc := &Discipline[Type]{
+		opts: opts,
+
+		retire:     make(chan struct{}),
+		terminated: make(chan struct{}),
+
+		stop: make(chan struct{}),
+
+		undispatched: make(map[uint][]Type),
+
+		done: make(chan struct{}),
+		err:  make(chan error, 1),
+	}
+
+	return dsc
+}
+
 // Returns a channel with errors. If an error occurs (the value from the channel
 // is not equal to nil) the discipline terminates its work.
 //
 // The single nil value means that the discipline has terminated in normal mode:
 // after closing and emptying all input channels.
 //
-// The only place where the error can occurs is the divider. If you are sure that the
-// divider is working correctly and the configuration used will not cause an error
-// in it, then you are not obliged to read from this channel and you are not obliged
-// to check the received value.
+// The error can occurs in the divider or, with the PanicTerminate policy, when a
+// handle function panics, then the error is of the [*PanicError] type. If you are
+// sure that the divider is working correctly and the configuration used will not
+// cause an error in it and that the handle function does not panic, then you are
+// not obliged to read from this channel and you are not obliged to check the
+// received value.
 func (dsc *Discipline[Type]) Err() <-chan error {
-	return dsc.core.Err()
+	return dsc.err
+}
+
+// Waits for the termination of the discipline and for the return of all its
+// handlers and returns the same error as the [Discipline.Err] channel.
+//
+// Unlike the [Discipline.Err] channel, it can be called any number of times and
+// from any goroutine.
+func (dsc *Discipline[Type]) Wait() error {
+	<-dsc.done
+
+	return dsc.result
+}
+
+// Gracefully terminates the discipline.
+//
+// Discipline stops receiving data items from the input channels and waits for the
+// completion of the handle functions that are already running until the context
+// is done. Data items that have been received from the input channels, including
+// retried ones, but have not been passed to the handle function are returned
+// grouped by priorities. Data items buffered in the input channels at the moment of
+// the call are also received and returned, but data items written to the input
+// channels later are not received.
+//
+// If the context is done before the termination, then its error is returned
+// along with the data items collected by that moment. Otherwise the same error
+// as from the [Discipline.Wait] method is returned.
+func (dsc *Discipline[Type]) Shutdown(ctx context.Context) (map[uint][]Type, error) {
+	dsc.stopper.Do(func() { close(dsc.stop) })
+
+	for priority, input := range dsc.opts.Inputs {
+		dsc.keepUndispatched(priority, drain(input)...)
+	}
+
+	select {
+	case <-ctx.Done():
+		return dsc.collectUndispatched(), ctx.Err()
+	case <-dsc.done:
+		return dsc.collectUndispatched(), dsc.result
+	}
+}
+
+// Receives data items buffered in the channel without blocking.
+func drain[Type any](channel <-chan Type) []Type {
+	items := make([]Type, 0, len(channel))
+
+	for range cap(items) {
+		select {
+		case item, opened := <-channel:
+			if !opened {
+				return items
+			}
+
+			items = append(items, item)
+		default:
+			// Buffered data items have been received by the discipline
+			return items
+		}
+	}
+
+	return items
+}
+
+func (dsc *Discipline[Type]) keepUndispatched(priority uint, items ...Type) {
+	if len(items) == 0 {
+		return
+	}
+
+	dsc.undispatchedMutex.Lock()
+	defer dsc.undispatchedMutex.Unlock()
+
+	dsc.undispatched[priority] = append(dsc.undispatched[priority], items...)
+}
+
+func (dsc *Discipline[Type]) collectUndispatched() map[uint][]Type {
+	dsc.undispatchedMutex.Lock()
+	defer dsc.undispatchedMutex.Unlock()
+
+	collected := make(map[uint][]Type, len(dsc.undispatched))
+
+	for priority, items := range dsc.undispatched {
+		collected[priority] = slices.Clone(items)
+	}
+
+	return collected
+}
+
+// Changes the quantity of data handlers of the running discipline.
+//
+// When the quantity is increased, additional handlers are started and used
+// immediately. When the quantity is decreased, this method blocks until enough data
+// items are processed and then the excess handlers are retired.
+//
+// It is safe to call this method from any goroutine.
+func (dsc *Discipline[Type]) SetHandlersQuantity(quantity uint) error {
+	dsc.mutex.Lock()
+	defer dsc.mutex.Unlock()
+
+	if err := dsc.core.SetHandlersQuantity(quantity); err != nil {
+		return err
+	}
+
+	// Underlying priority discipline could terminate after the change was applied
+	if dsc.finished {
+		return priocore.ErrDisciplineTerminated
+	}
+
+	if quantity > dsc.handlers {
+		dsc.start(quantity - dsc.handlers)
+		return nil
+	}
+
+	// Excess handlers are idle or will become idle, because the core discipline
+	// does not distribute data items to them anymore
+	for range dsc.handlers - quantity {
+		select {
+		case <-dsc.terminated:
+			return nil
+		case dsc.retire <- struct{}{}:
+		}
+	}
+
+	dsc.handlers = quantity
+
+	return nil
 }
 
 func (dsc *Discipline[Type]) main() {
-	for range dsc.opts.HandlersQuantity {
-		go dsc.handler()
+	// Reference held for the lifetime of the underlying priority discipline, so that
+	// the handlers can be started while it is running
+	dsc.wg.Add(1)
+
+	dsc.start(dsc.opts.HandlersQuantity)
+
+	go dsc.wait()
+}
+
+func (dsc *Discipline[Type]) wait() {
+	err := <-dsc.core.Err()
+
+	if panicked := dsc.panicked.Load(); panicked != nil && err == nil {
+		err = panicked
+	}
+
+	dsc.finish()
+	dsc.wg.Wait()
+
+	dsc.result = err
+	close(dsc.done)
+
+	dsc.err <- err
+	close(dsc.err)
+}
+
+// Prohibits the start of handlers and releases the reference held for the lifetime
+// of the underlying priority discipline.
+func (dsc *Discipline[Type]) finish() {
+	dsc.mutex.Lock()
+	defer dsc.mutex.Unlock()
+
+	dsc.finished = true
+
+	dsc.wg.Done()
+}
+
+func (dsc *Discipline[Type]) start(quantity uint) {
+	for range quantity {
+		dsc.wg.Add(1)
+
+		if dsc.plain != nil {
+			go serve(dsc, dsc.plain, dsc.processPlain)
+			continue
+		}
+
+		go serve(dsc, dsc.retrying, dsc.process)
+	}
+
+	dsc.handlers += quantity
+}
+
+// Handler that receives data items from the underlying priority discipline and
+// processes them.
+func serve[Type, Item any](
+	dsc *Discipline[Type],
+	core *priocore.Discipline[Item],
+	process func(prioritized priodefs.Prioritized[Item]),
+) {
+	defer dsc.wg.Done()
+
+	for {
+		select {
+		case <-dsc.retire:
+			return
+		case prioritized, opened := <-core.Output():
+			if !opened {
+				dsc.terminator.Do(func() { close(dsc.terminated) })
+				return
+			}
+
+			process(prioritized)
+			core.Release(prioritized.Priority)
+		}
+	}
+}
+
+func (dsc *Discipline[Type]) processPlain(prioritized priodefs.Prioritized[Type]) {
+	// Data items received after the termination is requested are skipped
+	if dsc.isStopped() {
+		dsc.keepUndispatched(prioritized.Priority, prioritized.Item)
+		return
+	}
+
+	if panicked, _ := dsc.call(prioritized); panicked != nil {
+		dsc.onPanic(panicked)
+	}
+}
+
+func (dsc *Discipline[Type]) process(prioritized priodefs.Prioritized[entry[Type]]) {
+	item := priodefs.Prioritized[Type]{
+		Item:     prioritized.Item.item,
+		Priority: prioritized.Priority,
+	}
+
+	qu := dsc.queues[prioritized.Priority]
+
+	// Data items received after the termination is requested are skipped
+	if dsc.isStopped() {
+		dsc.keepUndispatched(prioritized.Priority, item.Item)
+		qu.complete()
+
+		return
+	}
+
+	panicked, err := dsc.call(item)
+	if panicked != nil {
+		dsc.onPanic(panicked)
+		qu.complete()
+
+		return
+	}
+
+	if err == nil {
+		qu.complete()
+		return
+	}
+
+	// Integer overflow is impossible because the quantity of failed attempts never
+	// exceeds the maximum quantity of attempts
+	failed := prioritized.Item.failed + 1
+
+	if failed < dsc.opts.Retry.Attempts {
+		retried := entry[Type]{
+			failed: failed,
+			item:   prioritized.Item.item,
+		}
+
+		if qu.delay(retried, dsc.opts.Retry.delay(failed)) {
+			return
+		}
+
+		// Queue is drained due to the termination
+		dsc.keepUndispatched(prioritized.Priority, item.Item)
+		qu.complete()
+
+		return
+	}
+
+	if dsc.opts.DeadLetter != nil {
+		failure := Failure[Type]{
+			Attempts:    failed,
+			Err:         err,
+			Prioritized: item,
+		}
+
+		dsc.opts.DeadLetter <- failure
+	}
+
+	qu.complete()
+}
+
+// Calls the handle function and recovers a panic occurred in it.
+func (dsc *Discipline[Type]) call(item priodefs.Prioritized[Type]) (panicked *PanicError, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			panicked = &PanicError{
+				Priority: item.Priority,
+				Stack:    debug.Stack(),
+				Value:    value,
+			}
+		}
+	}()
+
+	if dsc.opts.Handle != nil {
+		dsc.opts.Handle(item)
+		return nil, nil
+	}
+
+	return nil, dsc.opts.HandleErr(item)
+}
+
+func (dsc *Discipline[Type]) onPanic(panicked *PanicError) {
+	if dsc.opts.PanicPolicy == PanicContinue {
+		if dsc.opts.Panics != nil {
+			dsc.opts.Panics <- panicked
+		}
+
+		return
+	}
+
+	dsc.stopper.Do(func() {
+		dsc.panicked.Store(panicked)
+		close(dsc.stop)
+	})
+}
+
+func (dsc *Discipline[Type]) isStopped() bool {
+	select {
+	case <-dsc.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stops the underlying priority discipline from receiving data items from the input
+// channels when the termination is requested.
+func (dsc *Discipline[Type]) halt() {
+	defer dsc.wg.Done()
+
+	select {
+	case <-dsc.terminated:
+	case <-dsc.stop:
+		// Error is returned only if the underlying priority discipline is already
+		// terminated
+		_ = hook.Stop(dsc.plain)
+	}
+}
+
+// Passes data items from the input channel and retried data items from the queue to
+// the underlying priority discipline. Closes the output channel when the input
+// channel is closed and all its data items are processed.
+func (dsc *Discipline[Type]) forward(
+	priority uint,
+	input <-chan Type,
+	output chan<- entry[Type],
+) {
+	defer dsc.wg.Done()
+	defer close(output)
+
+	qu := dsc.queues[priority]
+
+	// Retried data items are returned to the caller of the Shutdown method
+	defer func() { dsc.keepUndispatched(priority, qu.drain()...) }()
+
+	for {
+		if retried, exists := qu.pop(); exists {
+			if !dsc.pass(output, retried) {
+				// Data item is not passed due to the termination, so it is returned
+				// to the caller of the Shutdown method
+				dsc.keepUndispatched(priority, retried.item)
+				qu.complete()
+
+				return
+			}
+
+			continue
+		}
+
+		if input == nil && qu.isIdle() {
+			return
+		}
+
+		select {
+		case <-dsc.terminated:
+			return
+		case <-dsc.stop:
+			return
+		case <-qu.notify:
+		case item, opened := <-input:
+			if !opened {
+				input = nil
+				continue
+			}
+
+			qu.acquire()
+
+			if !dsc.pass(output, entry[Type]{item: item}) {
+				dsc.keepUndispatched(priority, item)
+				qu.complete()
+
+				return
+			}
+		}
 	}
 }
 
-func (dsc *Discipline[Type]) handler() {
-	for prioritized := range dsc.core.Output() {
-		dsc.opts.Handle(prioritized)
-		dsc.core.Release(prioritized.Priority)
+func (dsc *Discipline[Type]) pass(output chan<- entry[Type], passed entry[Type]) bool {
+	select {
+	case <-dsc.terminated:
+		return false
+	case <-dsc.stop:
+		return false
+	case output <- passed:
+		return true
 	}
 }
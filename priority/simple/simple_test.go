@@ -1,7 +1,9 @@
 package simple
 
 import (
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/akramarenkov/flow/priority"
 	"github.com/akramarenkov/flow/priority/divider"
@@ -77,6 +79,138 @@ func TestDisciplineError(t *testing.T) {
 	testDiscipline(t, wrong, true)
 }
 
+func TestDisciplineErrClosed(t *testing.T) {
+	input := make(chan int, 1)
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           func(priodefs.Prioritized[int]) {},
+		HandlersQuantity: 1,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	input <- 1
+	close(input)
+
+	errs := make([]error, 0, 1)
+
+	// Channel is closed after the single value is sent, so it can be ranged over
+	for err := range discipline.Err() {
+		errs = append(errs, err)
+	}
+
+	require.Equal(t, []error{nil}, errs)
+}
+
+func TestDisciplineSetHandlersQuantity(t *testing.T) {
+	const (
+		handlingDuration = 10 * time.Millisecond
+		itemsQuantity    = 60
+	)
+
+	input := make(chan int)
+	processed := make(chan struct{}, itemsQuantity)
+
+	var (
+		concurrent atomic.Int64
+		maximum    atomic.Int64
+	)
+
+	handle := func(priodefs.Prioritized[int]) {
+		current := concurrent.Add(1)
+		defer concurrent.Add(-1)
+
+		for {
+			stored := maximum.Load()
+			if current <= stored || maximum.CompareAndSwap(stored, current) {
+				break
+			}
+		}
+
+		time.Sleep(handlingDuration)
+
+		processed <- struct{}{}
+	}
+
+	opts := Opts[int]{
+		Divider:          divider.Fair,
+		Handle:           handle,
+		HandlersQuantity: 2,
+		Inputs: map[uint]<-chan int{
+			1: input,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	require.Error(t, discipline.SetHandlersQuantity(0))
+
+	process := func() {
+		maximum.Store(0)
+
+		for item := range itemsQuantity {
+			input <- item
+		}
+
+		for range itemsQuantity {
+			<-processed
+		}
+	}
+
+	require.NoError(t, discipline.SetHandlersQuantity(6))
+	process()
+	require.Greater(t, maximum.Load(), int64(2))
+	require.LessOrEqual(t, maximum.Load(), int64(6))
+
+	require.NoError(t, discipline.SetHandlersQuantity(1))
+	process()
+	require.Equal(t, int64(1), maximum.Load())
+
+	close(input)
+
+	require.NoError(t, <-discipline.Err())
+	require.Error(t, discipline.SetHandlersQuantity(2))
+}
+
+func TestDisciplineSetHandlersQuantityTermination(t *testing.T) {
+	const repetitions = 100
+
+	for range repetitions {
+		input := make(chan int)
+
+		opts := Opts[int]{
+			Divider:          divider.Fair,
+			Handle:           func(priodefs.Prioritized[int]) {},
+			HandlersQuantity: 1,
+			Inputs: map[uint]<-chan int{
+				1: input,
+			},
+		}
+
+		discipline, err := New(opts)
+		require.NoError(t, err)
+
+		close(input)
+
+		// Handlers must not be started after the termination of the underlying
+		// priority discipline
+		for quantity := uint(2); ; quantity++ {
+			if err := discipline.SetHandlersQuantity(quantity); err != nil {
+				require.ErrorIs(t, err, priority.ErrDisciplineTerminated)
+				break
+			}
+		}
+
+		require.NoError(t, discipline.Wait())
+	}
+}
+
 func testDiscipline(t *testing.T, divisor priodefs.Divider, isErrorExpected bool) {
 	handlersQuantity := uint(6)
 	itemsQuantity := 100000
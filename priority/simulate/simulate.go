@@ -0,0 +1,120 @@
+// Package is used to simulate the work of the priority discipline with the
+// specified divider and quantity of data handlers according to a scenario of writing
+// data items to the input channels and of their processing.
+package simulate
+
+import (
+	"time"
+
+	"github.com/akramarenkov/flow/priority"
+	"github.com/akramarenkov/flow/priority/internal/measuring"
+	"github.com/akramarenkov/flow/priority/priodefs"
+)
+
+var (
+	ErrHandlersQuantityZero = measuring.ErrHandlersQuantityZero
+)
+
+// Describes one measuring of the processing sequence of the data item.
+type Measure = measuring.Measure
+
+// Type of one measuring. Specifies the position of the measurement in the
+// processing sequence of the data item.
+type Kind = measuring.Kind
+
+const (
+	// Data item is released by the data handler
+	KindCompleted = measuring.KindCompleted
+	// Processing of data item by the data handler is finished
+	KindProcessed = measuring.KindProcessed
+	// Data item is received by the data handler from the discipline
+	KindReceived = measuring.KindReceived
+	// Data item is going to be written to the input channel of the discipline
+	KindWritten = measuring.KindWritten
+)
+
+// Simulator of the priority discipline.
+type Simulator struct {
+	measurer *measuring.Measurer
+}
+
+// Creates Simulator instance.
+//
+// If the capacity of the input (for the discipline) channels is not specified,
+// then the capacity of each channel will be equal to the quantity of data handlers.
+//
+// When specifying multiple input channel capacities, the value of the first
+// one will be used.
+func New(handlersQuantity uint, inputCapacity ...uint) (*Simulator, error) {
+	measurer, err := measuring.NewMeasurer(handlersQuantity, inputCapacity...)
+	if err != nil {
+		return nil, err
+	}
+
+	sml := &Simulator{
+		measurer: measurer,
+	}
+
+	return sml, nil
+}
+
+// Returns the quantity of data handlers specified when the instance was created.
+func (sml *Simulator) HandlersQuantity() uint {
+	return sml.measurer.HandlersQuantity()
+}
+
+// Adds to the scenario a write of the specified quantity of data items to the input
+// (for the discipline) channel of the specified priority.
+func (sml *Simulator) AddWrite(priority, quantity uint) {
+	sml.measurer.AddWrite(priority, quantity)
+}
+
+// Adds to the scenario a write of the specified quantity of data items to the input
+// (for the discipline) channel of the specified priority.
+//
+// Before writing each data item, the specified delay occurs.
+func (sml *Simulator) AddWriteWithDelay(priority, quantity uint, delay time.Duration) {
+	sml.measurer.AddWriteWithDelay(priority, quantity, delay)
+}
+
+// Adds to the scenario a waiting for the input (for the discipline) channel of the
+// specified priority to be devastated.
+func (sml *Simulator) AddWaitDevastation(priority uint) {
+	sml.measurer.AddWaitDevastation(priority)
+}
+
+// Adds to the scenario a delay in further writing to the input (for the discipline)
+// channel of the specified priority.
+func (sml *Simulator) AddDelay(priority uint, delay time.Duration) {
+	sml.measurer.AddDelay(priority, delay)
+}
+
+// Sets the processing duration of one data item of the specified priority received
+// from the discipline.
+func (sml *Simulator) SetProcessingDuration(priority uint, duration time.Duration) {
+	sml.measurer.SetProcessingDuration(priority, duration)
+}
+
+// Creates the priority discipline with the specified divider, plays the scenario
+// against it and returns measurements of the processing of all data items.
+//
+// Can be called several times, for example, to compare dividers.
+func (sml *Simulator) Run(divider priodefs.Divider) ([]Measure, error) {
+	opts := priority.Opts[uint]{
+		Divider:          divider,
+		HandlersQuantity: sml.measurer.HandlersQuantity(),
+		Inputs:           sml.measurer.Inputs(),
+	}
+
+	discipline, err := priority.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return sml.measurer.Play(discipline)
+}
+
+// Calculates summary statistics of the measurements by priorities.
+func (sml *Simulator) Summarize(measurements []Measure) map[uint]Summary {
+	return summarize(measurements, sml.measurer.HandlersQuantity())
+}
@@ -0,0 +1,84 @@
+package simulate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akramarenkov/flow/priority"
+	"github.com/akramarenkov/flow/priority/divider"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulator(t *testing.T) {
+	const itemsQuantity = 300
+
+	sml, err := New(6)
+	require.NoError(t, err)
+	require.Equal(t, uint(6), sml.HandlersQuantity())
+
+	for priority := uint(1); priority <= 3; priority++ {
+		sml.AddWrite(priority, itemsQuantity)
+		sml.SetProcessingDuration(priority, 2*time.Millisecond)
+	}
+
+	fair, err := sml.Run(divider.Fair)
+	require.NoError(t, err)
+	require.Len(t, fair, 3*itemsQuantity*4)
+
+	rate, err := sml.Run(divider.Rate)
+	require.NoError(t, err)
+	require.Len(t, rate, 3*itemsQuantity*4)
+
+	fairSummaries := sml.Summarize(fair)
+	rateSummaries := sml.Summarize(rate)
+
+	require.Len(t, fairSummaries, 3)
+	require.Len(t, rateSummaries, 3)
+
+	for priority := uint(1); priority <= 3; priority++ {
+		require.Equal(t, uint(itemsQuantity), fairSummaries[priority].Quantity)
+		require.Equal(t, uint(itemsQuantity), rateSummaries[priority].Quantity)
+	}
+
+	// Data items of the highest priority are processed faster with divider.Rate
+	require.Greater(t, rateSummaries[3].Share, rateSummaries[1].Share)
+	require.Greater(t, rateSummaries[3].Throughput, fairSummaries[3].Throughput)
+	require.Less(t, rateSummaries[3].Duration, fairSummaries[3].Duration)
+}
+
+func TestSimulatorScenario(t *testing.T) {
+	sml, err := New(6, 0)
+	require.NoError(t, err)
+
+	sml.AddWrite(1, 100)
+	sml.AddWaitDevastation(1)
+	sml.AddDelay(1, 10*time.Millisecond)
+	sml.AddWriteWithDelay(1, 10, time.Millisecond)
+
+	measurements, err := sml.Run(divider.Fair)
+	require.NoError(t, err)
+	require.Len(t, measurements, 110*4)
+
+	summaries := sml.Summarize(measurements)
+	require.Equal(t, uint(110), summaries[1].Quantity)
+	require.Greater(t, summaries[1].Duration, 10*time.Millisecond)
+}
+
+func TestSimulatorError(t *testing.T) {
+	_, err := New(0)
+	require.ErrorIs(t, err, ErrHandlersQuantityZero)
+
+	sml, err := New(2)
+	require.NoError(t, err)
+
+	sml.AddWrite(1, 10)
+	sml.AddWrite(2, 10)
+	sml.AddWrite(3, 10)
+
+	_, err = sml.Run(divider.Fair)
+	require.ErrorIs(t, err, priority.ErrHandlersQuantityTooSmall)
+
+	_, err = sml.Run(nil)
+	require.ErrorIs(t, err, priority.ErrDividerEmpty)
+}
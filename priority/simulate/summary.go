@@ -0,0 +1,142 @@
+package simulate
+
+import (
+	"slices"
+	"time"
+
+	"github.com/akramarenkov/flow/internal/consts"
+)
+
+const (
+	percentP50 = 50
+	percentP90 = 90
+	percentP99 = 99
+)
+
+// Percentiles of the latency of data items.
+type Percentiles struct {
+	Max time.Duration
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+// Summary statistics of the processing of data items of one priority.
+//
+// Statistics are calculated over the active span of the priority, from the first
+// write of its data item to the input channel to the last release of its data item.
+type Summary struct {
+	// Duration of the active span of the priority
+	Duration time.Duration
+
+	// Latency of data items - time from the beginning of writing of the data item to
+	// the input channel to its receiving by the data handler
+	Latency Percentiles
+
+	// Quantity of processed data items
+	Quantity uint
+
+	// Average share of data handlers busy with data items of the priority during its
+	// active span, from 0 to 1
+	Share float64
+
+	// Quantity of data items processed per second during the active span of the
+	// priority
+	Throughput float64
+}
+
+// Times of the processing sequence of one data item.
+type track struct {
+	completed time.Duration
+	priority  uint
+	received  time.Duration
+	written   time.Duration
+}
+
+func summarize(measurements []Measure, handlersQuantity uint) map[uint]Summary {
+	tracks := make(map[uint]track)
+
+	for _, measure := range measurements {
+		current := tracks[measure.Item]
+		current.priority = measure.Priority
+
+		switch measure.Kind {
+		case KindCompleted:
+			current.completed = measure.Time
+		case KindReceived:
+			current.received = measure.Time
+		case KindWritten:
+			current.written = measure.Time
+		}
+
+		tracks[measure.Item] = current
+	}
+
+	type accumulator struct {
+		begin     time.Duration
+		busy      time.Duration
+		end       time.Duration
+		latencies []time.Duration
+	}
+
+	accumulators := make(map[uint]*accumulator)
+
+	for _, current := range tracks {
+		acc := accumulators[current.priority]
+
+		if acc == nil {
+			acc = &accumulator{
+				begin: current.written,
+				end:   current.completed,
+			}
+
+			accumulators[current.priority] = acc
+		}
+
+		acc.begin = min(acc.begin, current.written)
+		acc.end = max(acc.end, current.completed)
+		acc.busy += current.completed - current.received
+		acc.latencies = append(acc.latencies, max(current.received-current.written, 0))
+	}
+
+	summaries := make(map[uint]Summary, len(accumulators))
+
+	for priority, acc := range accumulators {
+		slices.Sort(acc.latencies)
+
+		summary := Summary{
+			Duration: acc.end - acc.begin,
+			Latency: Percentiles{
+				Max: acc.latencies[len(acc.latencies)-1],
+				P50: percentile(acc.latencies, percentP50),
+				P90: percentile(acc.latencies, percentP90),
+				P99: percentile(acc.latencies, percentP99),
+			},
+			Quantity: uint(len(acc.latencies)),
+		}
+
+		if summary.Duration > 0 {
+			capacity := float64(handlersQuantity) * float64(summary.Duration)
+
+			summary.Share = float64(acc.busy) / capacity
+			summary.Throughput = float64(summary.Quantity) / summary.Duration.Seconds()
+		}
+
+		summaries[priority] = summary
+	}
+
+	return summaries
+}
+
+// Returns the percentile of the sorted values by the nearest-rank method.
+func percentile(sorted []time.Duration, percent uint) time.Duration {
+	// Integer overflow is impossible because the percent does not exceed 100 and
+	// the quantity of values is limited by the available memory
+	rank := (uint(len(sorted))*percent + consts.HundredPercent - 1) / consts.HundredPercent
+
+	if rank == 0 {
+		return sorted[0]
+	}
+
+	return sorted[rank-1]
+}
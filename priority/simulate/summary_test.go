@@ -0,0 +1,66 @@
+package simulate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	measurements := []Measure{
+		{Item: 0, Kind: KindWritten, Priority: 2, Time: 0},
+		{Item: 0, Kind: KindReceived, Priority: 2, Time: time.Second},
+		{Item: 0, Kind: KindProcessed, Priority: 2, Time: 3 * time.Second},
+		{Item: 0, Kind: KindCompleted, Priority: 2, Time: 3 * time.Second},
+		{Item: 1, Kind: KindWritten, Priority: 2, Time: 0},
+		{Item: 1, Kind: KindReceived, Priority: 2, Time: 3 * time.Second},
+		{Item: 1, Kind: KindProcessed, Priority: 2, Time: 4 * time.Second},
+		{Item: 1, Kind: KindCompleted, Priority: 2, Time: 4 * time.Second},
+		{Item: 2, Kind: KindWritten, Priority: 1, Time: time.Second},
+		{Item: 2, Kind: KindReceived, Priority: 1, Time: time.Second},
+		{Item: 2, Kind: KindProcessed, Priority: 1, Time: 2 * time.Second},
+		{Item: 2, Kind: KindCompleted, Priority: 1, Time: 2 * time.Second},
+	}
+
+	expected := map[uint]Summary{
+		1: {
+			Duration: time.Second,
+			Quantity: 1,
+			Share:    0.5,
+			// Data item per second
+			Throughput: 1,
+		},
+		2: {
+			Duration: 4 * time.Second,
+			Latency: Percentiles{
+				Max: 3 * time.Second,
+				P50: time.Second,
+				P90: 3 * time.Second,
+				P99: 3 * time.Second,
+			},
+			Quantity:   2,
+			Share:      3.0 / 8.0,
+			Throughput: 0.5,
+		},
+	}
+
+	require.Equal(t, expected, summarize(measurements, 2))
+	require.Empty(t, summarize(nil, 2))
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0, 100)
+
+	for value := range time.Duration(100) {
+		sorted = append(sorted, value+1)
+	}
+
+	require.Equal(t, time.Duration(1), percentile(sorted, 0))
+	require.Equal(t, time.Duration(50), percentile(sorted, 50))
+	require.Equal(t, time.Duration(90), percentile(sorted, 90))
+	require.Equal(t, time.Duration(99), percentile(sorted, 99))
+	require.Equal(t, time.Duration(100), percentile(sorted, 100))
+
+	require.Equal(t, time.Duration(7), percentile([]time.Duration{7}, 99))
+}
@@ -0,0 +1,88 @@
+package priority
+
+import (
+	"maps"
+	"slices"
+)
+
+// Snapshot of the state of the discipline.
+//
+// All maps are keyed by priority.
+type KeyedStats[Key comparable] struct {
+	// Actual distribution of data items - quantity of data items written to the
+	// output channel, but not yet released
+	Actual map[Key]uint
+	// Closing state of input channels
+	Closed map[Key]bool
+	// Cumulative quantity of data items written to the output channel
+	Dispatched map[Key]uint64
+	// Current quantity of data handlers
+	HandlersQuantity uint
+	// Interim strategic distribution
+	Operative map[Key]uint
+	// Priorities of input channels sorted in descending order
+	Priorities []Key
+	// Cumulative quantity of released data items
+	Released map[Key]uint64
+	// Priorities whose input channels have been removed, but whose data items have
+	// not yet been released
+	Retired []Key
+	// Distribution of data items by priorities for the total quantity of data
+	// handlers
+	Strategic map[Key]uint
+	// Distribution on whose quantities input/output is performed
+	Tactic map[Key]uint
+	// Quantity of data handlers that are not busy with processing data items
+	VacantHandlers uint
+}
+
+// Returns a copy of the discipline state. Must be called only from the discipline
+// goroutine.
+func (dsc *Keyed[Key, Type]) snapshot() KeyedStats[Key] {
+	closed := make(map[Key]bool, len(dsc.inputs))
+
+	for priority, input := range dsc.inputs {
+		closed[priority] = input.Closed
+	}
+
+	stats := KeyedStats[Key]{
+		Actual:           maps.Clone(dsc.actual),
+		Closed:           closed,
+		Dispatched:       maps.Clone(dsc.dispatches),
+		HandlersQuantity: dsc.opts.HandlersQuantity,
+		Operative:        maps.Clone(dsc.operative),
+		Priorities:       slices.Clone(dsc.priorities),
+		Released:         maps.Clone(dsc.releases),
+		Retired:          slices.Clone(dsc.retired),
+		Strategic:        maps.Clone(dsc.strategic),
+		Tactic:           maps.Clone(dsc.tactic),
+	}
+
+	// Busy data handlers can exceed the total quantity only for a short time while
+	// the quantity of data handlers is being increased
+	if busy := dsc.busyHandlers(); busy < dsc.opts.HandlersQuantity {
+		stats.VacantHandlers = dsc.opts.HandlersQuantity - busy
+	}
+
+	return stats
+}
+
+// Snapshot of the state of the discipline with priorities of uint type.
+type Stats = KeyedStats[uint]
+
+// Answers the request for a snapshot of the discipline state.
+func (dsc *Keyed[Key, Type]) answer(request chan<- KeyedStats[Key]) {
+	request <- dsc.snapshot()
+}
+
+// Answers requests for a snapshot of the discipline state without blocking.
+func (dsc *Keyed[Key, Type]) answerAll() {
+	for {
+		select {
+		case request := <-dsc.stats:
+			dsc.answer(request)
+		default:
+			return
+		}
+	}
+}
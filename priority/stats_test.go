@@ -0,0 +1,117 @@
+package priority
+
+import (
+	"testing"
+
+	"github.com/akramarenkov/flow/priority/divider"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisciplineStats(t *testing.T) {
+	first := make(chan uint, 10)
+	second := make(chan uint, 10)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 4,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+			2: second,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	stats := discipline.Stats()
+	require.Equal(t, []uint{2, 1}, stats.Priorities)
+	require.Equal(t, map[uint]uint{1: 2, 2: 2}, stats.Strategic)
+	require.Equal(t, map[uint]bool{1: false, 2: false}, stats.Closed)
+	require.Equal(t, uint(4), stats.HandlersQuantity)
+	require.Equal(t, uint(4), stats.VacantHandlers)
+
+	for item := range uint(10) {
+		first <- item
+		second <- item
+	}
+
+	// All data handlers are busy and the discipline is waiting for releases
+	busy := make([]uint, 0, 4)
+
+	for range 4 {
+		prioritized := <-discipline.Output()
+		busy = append(busy, prioritized.Priority)
+	}
+
+	for stats.VacantHandlers != 0 {
+		stats = discipline.Stats()
+	}
+
+	require.Equal(t, map[uint]uint{1: 2, 2: 2}, stats.Actual)
+	require.Equal(t, map[uint]uint64{1: 2, 2: 2}, stats.Dispatched)
+	require.Empty(t, stats.Released)
+
+	for _, priority := range busy {
+		discipline.Release(priority)
+	}
+
+	close(first)
+	close(second)
+
+	for prioritized := range discipline.Output() {
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+}
+
+func TestDisciplineStatsTermination(t *testing.T) {
+	const itemsQuantity = 10
+
+	first := make(chan uint, itemsQuantity)
+	second := make(chan uint, itemsQuantity)
+
+	opts := Opts[uint]{
+		Divider:          divider.Fair,
+		HandlersQuantity: 4,
+		Inputs: map[uint]<-chan uint{
+			1: first,
+			2: second,
+		},
+	}
+
+	discipline, err := New(opts)
+	require.NoError(t, err)
+
+	for item := range uint(itemsQuantity) {
+		first <- item
+		second <- item
+	}
+
+	close(first)
+
+	for range 10 {
+		prioritized := <-discipline.Output()
+		discipline.Release(prioritized.Priority)
+	}
+
+	// Data items are not lost while gathering snapshots
+	stats := discipline.Stats()
+	require.Equal(t, []uint{2, 1}, stats.Priorities)
+
+	close(second)
+
+	for prioritized := range discipline.Output() {
+		discipline.Release(prioritized.Priority)
+	}
+
+	require.NoError(t, <-discipline.Err())
+
+	stats = discipline.Stats()
+	require.Equal(t, map[uint]bool{1: true, 2: true}, stats.Closed)
+	require.Equal(t, map[uint]uint{1: 0, 2: 0}, stats.Actual)
+	require.Equal(t, map[uint]uint64{1: itemsQuantity, 2: itemsQuantity}, stats.Dispatched)
+	require.Equal(t, map[uint]uint64{1: itemsQuantity, 2: itemsQuantity}, stats.Released)
+	require.Equal(t, uint(4), stats.VacantHandlers)
+}